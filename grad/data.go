@@ -6,8 +6,8 @@ package grad
 // apparently golang does not support constant floating point slices/arrays
 // so to prevent modification to input and labels data the follosing funcs were created
 
-func GetInputs() [][2]float64 {
-	return [][2]float64{
+func GetInputs() [][]float64 {
+	return [][]float64{
 		{5.39412337e-01, 8.61363932e-01},
 		{-1.03234535e+00, 5.77661126e-02},
 		{-1.12251058e+00, 4.40911069e-01},
@@ -0,0 +1,292 @@
+package grad
+
+import (
+	"fmt"
+	"math"
+)
+
+// Dual is a dual number Real + Deriv·ε (where ε² = 0) used for forward mode differentiation:
+// seeding the Deriv of the inputs with a direction, the Deriv of every result of its operations
+// is the derivative along that direction, computed together with the value in a single pass
+// its operations mirror the ones of Value, with the same derivatives at the points where they are not differentiable
+type Dual struct {
+	Real  float64
+	Deriv float64
+}
+
+func NewDual(real float64, deriv float64) Dual {
+	return Dual{Real: real, Deriv: deriv}
+}
+
+// a constant, i.e. with a derivative of 0
+func constDual(real float64) Dual {
+	return Dual{Real: real}
+}
+
+func (d Dual) Add(other Dual) Dual {
+	return Dual{d.Real + other.Real, d.Deriv + other.Deriv}
+}
+
+func (d Dual) Mul(other Dual) Dual {
+	return Dual{d.Real * other.Real, d.Deriv*other.Real + d.Real*other.Deriv}
+}
+
+func (d Dual) Sub(other Dual) Dual {
+	return d.Add(other.Neg())
+}
+
+// other can be a Dual, a float64 or an int
+func (d Dual) Div(other interface{}) Dual {
+	var o Dual
+
+	switch other.(type) {
+	case float64:
+		o = constDual(other.(float64))
+	case int:
+		o = constDual(float64(other.(int)))
+	case Dual:
+		o = other.(Dual)
+	}
+
+	return d.Mul(o.Pow(-1))
+}
+
+func (d Dual) Neg() Dual {
+	return Dual{-d.Real, -d.Deriv}
+}
+
+func (d Dual) Pow(exp float64) Dual {
+	return Dual{math.Pow(d.Real, exp), exp * math.Pow(d.Real, exp-1) * d.Deriv}
+}
+
+func (d Dual) Relu() Dual {
+	if d.Real < 0 {
+		return Dual{0, 0}
+	}
+
+	return Dual{math.Max(d.Real, 0), d.Deriv}
+}
+
+func (d Dual) Tanh() Dual {
+	t := math.Tanh(d.Real)
+
+	return Dual{t, (1 - t*t) * d.Deriv}
+}
+
+func (d Dual) Sigmoid() Dual {
+	s := sigmoid(d.Real)
+
+	return Dual{s, s * (1 - s) * d.Deriv}
+}
+
+func (d Dual) LeakyRelu(slope float64) Dual {
+	if d.Real <= 0 {
+		return Dual{slope * d.Real, slope * d.Deriv}
+	}
+
+	return d
+}
+
+func (d Dual) Elu(alpha float64) Dual {
+	if d.Real <= 0 {
+		e := alpha * (math.Exp(d.Real) - 1)
+		return Dual{e, (e + alpha) * d.Deriv}
+	}
+
+	return d
+}
+
+func (d Dual) Gelu() Dual {
+	cdf := 0.5 * (1 + math.Erf(d.Real/math.Sqrt2))
+	pdf := math.Exp(-0.5*d.Real*d.Real) / math.Sqrt(2*math.Pi)
+
+	return Dual{d.Real * cdf, (cdf + d.Real*pdf) * d.Deriv}
+}
+
+func (d Dual) Softplus() Dual {
+	return Dual{math.Log1p(math.Exp(-math.Abs(d.Real))) + math.Max(d.Real, 0), sigmoid(d.Real) * d.Deriv}
+}
+
+func (d Dual) Identity() Dual {
+	return d
+}
+
+func (d Dual) Exp() Dual {
+	e := math.Exp(d.Real)
+
+	return Dual{e, e * d.Deriv}
+}
+
+func (d Dual) Log() Dual {
+	return Dual{math.Log(d.Real), d.Deriv / d.Real}
+}
+
+func (d Dual) Sqrt() Dual {
+	r := math.Sqrt(d.Real)
+
+	return Dual{r, 0.5 / r * d.Deriv}
+}
+
+func (d Dual) Abs() Dual {
+	switch {
+	case d.Real > 0:
+		return d
+	case d.Real < 0:
+		return d.Neg()
+	}
+
+	return Dual{0, 0}
+}
+
+func (d Dual) Sin() Dual {
+	return Dual{math.Sin(d.Real), math.Cos(d.Real) * d.Deriv}
+}
+
+func (d Dual) Cos() Dual {
+	return Dual{math.Cos(d.Real), -math.Sin(d.Real) * d.Deriv}
+}
+
+// the derivative is the one of the larger operand (of d when they are equal)
+func (d Dual) Max(other Dual) Dual {
+	if d.Real >= other.Real {
+		return d
+	}
+
+	return other
+}
+
+// the derivative is the one of the smaller operand (of d when they are equal)
+func (d Dual) Min(other Dual) Dual {
+	if d.Real <= other.Real {
+		return d
+	}
+
+	return other
+}
+
+// the derivative is 0 when d is outside of [lo, hi]
+func (d Dual) Clamp(lo, hi float64) Dual {
+	if d.Real < lo {
+		return constDual(lo)
+	}
+	if d.Real > hi {
+		return constDual(hi)
+	}
+
+	return d
+}
+
+// JVP evaluates f at inputs and returns its outputs together with the product of its Jacobian
+// and direction, i.e. the derivative of each output along direction, in a single forward pass
+func JVP(f func([]Dual) []Dual, inputs []float64, direction []float64) ([]float64, []float64, error) {
+	if len(inputs) != len(direction) {
+		return nil, nil, fmt.Errorf("gograd: %d inputs but a direction of %d", len(inputs), len(direction))
+	}
+
+	xs := make([]Dual, len(inputs))
+	for i := range inputs {
+		xs[i] = NewDual(inputs[i], direction[i])
+	}
+
+	ys := f(xs)
+	outputs := make([]float64, len(ys))
+	jvp := make([]float64, len(ys))
+	for i, y := range ys {
+		outputs[i], jvp[i] = y.Real, y.Deriv
+	}
+
+	return outputs, jvp, nil
+}
+
+// DiffFunc is a function from several inputs to several outputs, written for both modes of differentiation
+// either one can be nil, in which case the other mode is always used
+type DiffFunc struct {
+	Forward func([]Dual) []Dual
+	Reverse func([]*Value) []*Value
+}
+
+// Jacobian returns the matrix of the derivatives of each output (rows) with respect to each input (columns)
+// forward mode takes a pass for each input and reverse mode a backward pass for each output,
+// so the former is used when there are no more inputs than outputs and the latter otherwise
+func Jacobian(f DiffFunc, inputs []float64) ([][]float64, error) {
+	switch {
+	case f.Forward == nil && f.Reverse == nil:
+		return nil, fmt.Errorf("gograd: no function to differentiate")
+	case f.Reverse == nil:
+		return jacobianForward(f.Forward, inputs), nil
+	case f.Forward == nil:
+		return jacobianReverse(f.Reverse, inputs), nil
+	}
+
+	// a forward pass tells how many outputs there are
+	ys := f.Forward(dualInputs(inputs, 0))
+	if len(inputs) <= len(ys) {
+		return jacobianForward(f.Forward, inputs), nil
+	}
+
+	return jacobianReverse(f.Reverse, inputs), nil
+}
+
+// a column for each forward pass, seeding one input at a time
+func jacobianForward(f func([]Dual) []Dual, inputs []float64) [][]float64 {
+	var jac [][]float64
+
+	for j := range inputs {
+		ys := f(dualInputs(inputs, j))
+		if jac == nil {
+			jac = make([][]float64, len(ys))
+			for i := range jac {
+				jac[i] = make([]float64, len(inputs))
+			}
+		}
+		for i, y := range ys {
+			jac[i][j] = y.Deriv
+		}
+	}
+
+	return jac
+}
+
+// a row for each backward pass, from one output at a time
+func jacobianReverse(f func([]*Value) []*Value, inputs []float64) [][]float64 {
+	xs := make([]*Value, len(inputs))
+	for i, x := range inputs {
+		xs[i] = NewValue(x)
+	}
+
+	ys := f(xs)
+	jac := make([][]float64, len(ys))
+	for i, y := range ys {
+		// gradients accumulate, so the ones left by the previous output are cleared
+		visited := map[*Value]bool{}
+		tpOrder := []*Value{}
+		topologicalSort(y, &visited, &tpOrder)
+		for _, node := range tpOrder {
+			node.grad = 0
+		}
+		for _, x := range xs {
+			x.grad = 0
+		}
+
+		y.BackwardPass()
+		jac[i] = make([]float64, len(xs))
+		for j, x := range xs {
+			jac[i][j] = x.GetGrad()
+		}
+	}
+
+	return jac
+}
+
+// inputs as constants, except the one at index seed whose derivative is 1
+func dualInputs(inputs []float64, seed int) []Dual {
+	xs := make([]Dual, len(inputs))
+	for i, x := range inputs {
+		xs[i] = constDual(x)
+	}
+	if seed < len(xs) {
+		xs[seed].Deriv = 1.0
+	}
+
+	return xs
+}
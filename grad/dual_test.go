@@ -0,0 +1,144 @@
+package grad
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDualOps(t *testing.T) {
+	// each op of Dual is checked against the same op of Value and its BackwardPass
+	dualTestTable := []struct {
+		name string
+		v    func(*Value) *Value
+		d    func(Dual) Dual
+		x    float64
+	}{
+		{"Add", func(x *Value) *Value { return x.Add(NewValue(2.0)) }, func(x Dual) Dual { return x.Add(constDual(2.0)) }, 0.7},
+		{"Mul", func(x *Value) *Value { return x.Mul(x) }, func(x Dual) Dual { return x.Mul(x) }, -1.3},
+		{"Sub", func(x *Value) *Value { return NewValue(1.0).Sub(x) }, func(x Dual) Dual { return constDual(1.0).Sub(x) }, 0.4},
+		{"Div", func(x *Value) *Value { return NewValue(3.0).Div(x) }, func(x Dual) Dual { return constDual(3.0).Div(x) }, 1.6},
+		{"Div", func(x *Value) *Value { return x.Div(4) }, func(x Dual) Dual { return x.Div(4) }, 1.6},
+		{"Neg", (*Value).Neg, Dual.Neg, 0.2},
+		{"Pow", func(x *Value) *Value { return x.Pow(3) }, func(x Dual) Dual { return x.Pow(3) }, -0.9},
+		{"Relu", (*Value).Relu, Dual.Relu, 0.5},
+		{"Relu", (*Value).Relu, Dual.Relu, -0.5},
+		{"Tanh", (*Value).Tanh, Dual.Tanh, 0.3},
+		{"Sigmoid", (*Value).Sigmoid, Dual.Sigmoid, -2.1},
+		{"LeakyRelu", func(x *Value) *Value { return x.LeakyRelu(0.01) }, func(x Dual) Dual { return x.LeakyRelu(0.01) }, -0.5},
+		{"Elu", func(x *Value) *Value { return x.Elu(1.0) }, func(x Dual) Dual { return x.Elu(1.0) }, -0.5},
+		{"Elu", func(x *Value) *Value { return x.Elu(1.0) }, func(x Dual) Dual { return x.Elu(1.0) }, 0.5},
+		{"Gelu", (*Value).Gelu, Dual.Gelu, -0.8},
+		{"Softplus", (*Value).Softplus, Dual.Softplus, 1.2},
+		{"Identity", (*Value).Identity, Dual.Identity, 1.2},
+		{"Exp", (*Value).Exp, Dual.Exp, 0.6},
+		{"Log", (*Value).Log, Dual.Log, 0.6},
+		{"Sqrt", (*Value).Sqrt, Dual.Sqrt, 2.5},
+		{"Abs", (*Value).Abs, Dual.Abs, -2.5},
+		{"Sin", (*Value).Sin, Dual.Sin, 1.1},
+		{"Cos", (*Value).Cos, Dual.Cos, 1.1},
+		{"Max", func(x *Value) *Value { return x.Max(x.Mul(x)) }, func(x Dual) Dual { return x.Max(x.Mul(x)) }, 0.5},
+		{"Min", func(x *Value) *Value { return x.Min(x.Mul(x)) }, func(x Dual) Dual { return x.Min(x.Mul(x)) }, 0.5},
+		{"Clamp", func(x *Value) *Value { return x.Clamp(-1, 1) }, func(x Dual) Dual { return x.Clamp(-1, 1) }, 0.5},
+		{"Clamp", func(x *Value) *Value { return x.Clamp(-1, 1) }, func(x Dual) Dual { return x.Clamp(-1, 1) }, 1.5},
+	}
+
+	for _, tc := range dualTestTable {
+		v := NewValue(tc.x)
+		out := tc.v(v)
+		out.BackwardPass()
+		d := tc.d(NewDual(tc.x, 1.0))
+
+		if math.Abs(d.Real-out.GetData()) > 1e-12 {
+			t.Errorf("Dual %s at %f was incorrect, got:%f, want:%f", tc.name, tc.x, d.Real, out.GetData())
+		}
+		if math.Abs(d.Deriv-v.GetGrad()) > 1e-12 {
+			t.Errorf("Dual %s derivative at %f was incorrect, got:%f, want:%f", tc.name, tc.x, d.Deriv, v.GetGrad())
+		}
+	}
+}
+
+// polar to cartesian coordinates plus the squared radius, written for both modes
+var polar = DiffFunc{
+	Forward: func(x []Dual) []Dual {
+		return []Dual{x[0].Mul(x[1].Cos()), x[0].Mul(x[1].Sin()), x[0].Pow(2)}
+	},
+	Reverse: func(x []*Value) []*Value {
+		return []*Value{x[0].Mul(x[1].Cos()), x[0].Mul(x[1].Sin()), x[0].Pow(2)}
+	},
+}
+
+// a scalar function of three inputs, written for both modes
+var energy = DiffFunc{
+	Forward: func(x []Dual) []Dual {
+		return []Dual{x[0].Mul(x[1]).Add(x[2].Exp()).Tanh()}
+	},
+	Reverse: func(x []*Value) []*Value {
+		return []*Value{x[0].Mul(x[1]).Add(x[2].Exp()).Tanh()}
+	},
+}
+
+func TestJacobian(t *testing.T) {
+	jacobianTestTable := []struct {
+		name   string
+		f      DiffFunc
+		inputs []float64
+	}{
+		{"polar", polar, []float64{2.0, 0.7}},
+		{"energy", energy, []float64{0.3, -0.5, 0.1}},
+	}
+
+	for _, tc := range jacobianTestTable {
+		fwd := jacobianForward(tc.f.Forward, tc.inputs)
+		rev := jacobianReverse(tc.f.Reverse, tc.inputs)
+		jac, err := Jacobian(tc.f, tc.inputs)
+		if err != nil {
+			t.Fatalf("Jacobian of %s failed with error: %v", tc.name, err)
+		}
+
+		if len(fwd) != len(rev) || len(jac) != len(rev) {
+			t.Fatalf("Jacobian of %s has the wrong number of rows, got:%d and %d, want:%d", tc.name, len(fwd), len(jac), len(rev))
+		}
+		for i := range rev {
+			for j := range rev[i] {
+				if math.Abs(fwd[i][j]-rev[i][j]) > 1e-12 || math.Abs(jac[i][j]-rev[i][j]) > 1e-12 {
+					t.Errorf("Jacobian of %s at (%d, %d) was incorrect, forward:%f, chosen:%f, reverse:%f", tc.name, i, j, fwd[i][j], jac[i][j], rev[i][j])
+				}
+			}
+		}
+	}
+
+	// each mode on its own
+	if jac, err := Jacobian(DiffFunc{Reverse: polar.Reverse}, []float64{1.0, 0.0}); err != nil || jac[0][0] != 1.0 {
+		t.Errorf("Jacobian with the reverse mode only was incorrect, got:%v, error:%v", jac, err)
+	}
+	if jac, err := Jacobian(DiffFunc{Forward: polar.Forward}, []float64{1.0, 0.0}); err != nil || jac[1][1] != 1.0 {
+		t.Errorf("Jacobian with the forward mode only was incorrect, got:%v, error:%v", jac, err)
+	}
+	if _, err := Jacobian(DiffFunc{}, []float64{1.0}); err == nil {
+		t.Errorf("Jacobian without a function should have failed")
+	}
+}
+
+func TestJVP(t *testing.T) {
+	inputs, dir := []float64{2.0, 0.7}, []float64{0.5, -1.0}
+
+	outputs, jvp, err := JVP(polar.Forward, inputs, dir)
+	if err != nil {
+		t.Fatalf("JVP failed with error: %v", err)
+	}
+
+	jac := jacobianReverse(polar.Reverse, inputs)
+	for i := range jac {
+		want := jac[i][0]*dir[0] + jac[i][1]*dir[1]
+		if math.Abs(jvp[i]-want) > 1e-12 {
+			t.Errorf("JVP at index %d was incorrect, got:%f, want:%f", i, jvp[i], want)
+		}
+	}
+	if math.Abs(outputs[2]-4.0) > 1e-12 {
+		t.Errorf("JVP output was incorrect, got:%f, want:%f", outputs[2], 4.0)
+	}
+
+	if _, _, err := JVP(polar.Forward, inputs, dir[:1]); err == nil {
+		t.Errorf("JVP with a direction of the wrong length should have failed")
+	}
+}
@@ -1,12 +1,14 @@
 package grad
 
 import (
+	"fmt"
 	"math"
 )
 
 // === START OF OPERATIONS ARITHMETIC TYPE ===
 type operation interface {
 	isOperation()
+	symbol() string
 }
 
 // === START OF VARIANTS ===
@@ -23,6 +25,10 @@ func NewAddition() Addition {
 
 func (a Addition) isOperation() {}
 
+func (a Addition) symbol() string {
+	return a.operand
+}
+
 // subtraction variant
 type Subtraction struct {
 	operand string
@@ -36,6 +42,10 @@ func NewSubtraction() Subtraction {
 
 func (s Subtraction) isOperation() {}
 
+func (s Subtraction) symbol() string {
+	return s.operand
+}
+
 // multiplication variant
 type Multiplication struct {
 	operand string
@@ -49,6 +59,10 @@ func NewMultiplication() Multiplication {
 
 func (m Multiplication) isOperation() {}
 
+func (m Multiplication) symbol() string {
+	return m.operand
+}
+
 // division variant
 type Division struct {
 	operand string
@@ -62,6 +76,10 @@ func NewDivision() Division {
 
 func (d Division) isOperation() {}
 
+func (d Division) symbol() string {
+	return d.operand
+}
+
 // negation variant
 type Negation struct {
 	operand string
@@ -75,6 +93,10 @@ func NewNegation() Negation {
 
 func (d Negation) isOperation() {}
 
+func (d Negation) symbol() string {
+	return d.operand
+}
+
 // power variant
 type Power struct {
 	operand string
@@ -88,6 +110,10 @@ func NewPower() Power {
 
 func (p Power) isOperation() {}
 
+func (p Power) symbol() string {
+	return p.operand
+}
+
 // relu variant
 type Relu struct {
 	operand string
@@ -101,6 +127,299 @@ func NewRelu() Relu {
 
 func (r Relu) isOperation() {}
 
+func (r Relu) symbol() string {
+	return r.operand
+}
+
+// tanh variant
+type Tanh struct {
+	operand string
+}
+
+func NewTanh() Tanh {
+	return Tanh{
+		operand: "Tanh",
+	}
+}
+
+func (t Tanh) isOperation() {}
+
+func (t Tanh) symbol() string {
+	return t.operand
+}
+
+// sigmoid variant
+type Sigmoid struct {
+	operand string
+}
+
+func NewSigmoid() Sigmoid {
+	return Sigmoid{
+		operand: "Sigmoid",
+	}
+}
+
+func (s Sigmoid) isOperation() {}
+
+func (s Sigmoid) symbol() string {
+	return s.operand
+}
+
+// leaky relu variant
+type LeakyRelu struct {
+	operand string
+}
+
+func NewLeakyRelu() LeakyRelu {
+	return LeakyRelu{
+		operand: "LeakyReLU",
+	}
+}
+
+func (l LeakyRelu) isOperation() {}
+
+func (l LeakyRelu) symbol() string {
+	return l.operand
+}
+
+// elu variant
+type Elu struct {
+	operand string
+}
+
+func NewElu() Elu {
+	return Elu{
+		operand: "ELU",
+	}
+}
+
+func (e Elu) isOperation() {}
+
+func (e Elu) symbol() string {
+	return e.operand
+}
+
+// gelu variant
+type Gelu struct {
+	operand string
+}
+
+func NewGelu() Gelu {
+	return Gelu{
+		operand: "GELU",
+	}
+}
+
+func (g Gelu) isOperation() {}
+
+func (g Gelu) symbol() string {
+	return g.operand
+}
+
+// softplus variant
+type Softplus struct {
+	operand string
+}
+
+func NewSoftplus() Softplus {
+	return Softplus{
+		operand: "Softplus",
+	}
+}
+
+func (s Softplus) isOperation() {}
+
+func (s Softplus) symbol() string {
+	return s.operand
+}
+
+// identity variant
+type Identity struct {
+	operand string
+}
+
+func NewIdentity() Identity {
+	return Identity{
+		operand: "Identity",
+	}
+}
+
+func (i Identity) isOperation() {}
+
+func (i Identity) symbol() string {
+	return i.operand
+}
+
+// exponential variant
+type Exponential struct {
+	operand string
+}
+
+func NewExponential() Exponential {
+	return Exponential{
+		operand: "exp",
+	}
+}
+
+func (e Exponential) isOperation() {}
+
+func (e Exponential) symbol() string {
+	return e.operand
+}
+
+// logarithm variant
+type Logarithm struct {
+	operand string
+}
+
+func NewLogarithm() Logarithm {
+	return Logarithm{
+		operand: "log",
+	}
+}
+
+func (l Logarithm) isOperation() {}
+
+func (l Logarithm) symbol() string {
+	return l.operand
+}
+
+// square root variant
+type SquareRoot struct {
+	operand string
+}
+
+func NewSquareRoot() SquareRoot {
+	return SquareRoot{
+		operand: "sqrt",
+	}
+}
+
+func (s SquareRoot) isOperation() {}
+
+func (s SquareRoot) symbol() string {
+	return s.operand
+}
+
+// absolute value variant
+type Absolute struct {
+	operand string
+}
+
+func NewAbsolute() Absolute {
+	return Absolute{
+		operand: "abs",
+	}
+}
+
+func (a Absolute) isOperation() {}
+
+func (a Absolute) symbol() string {
+	return a.operand
+}
+
+// sine variant
+type Sine struct {
+	operand string
+}
+
+func NewSine() Sine {
+	return Sine{
+		operand: "sin",
+	}
+}
+
+func (s Sine) isOperation() {}
+
+func (s Sine) symbol() string {
+	return s.operand
+}
+
+// cosine variant
+type Cosine struct {
+	operand string
+}
+
+func NewCosine() Cosine {
+	return Cosine{
+		operand: "cos",
+	}
+}
+
+func (c Cosine) isOperation() {}
+
+func (c Cosine) symbol() string {
+	return c.operand
+}
+
+// maximum variant
+type Maximum struct {
+	operand string
+}
+
+func NewMaximum() Maximum {
+	return Maximum{
+		operand: "max",
+	}
+}
+
+func (m Maximum) isOperation() {}
+
+func (m Maximum) symbol() string {
+	return m.operand
+}
+
+// minimum variant
+type Minimum struct {
+	operand string
+}
+
+func NewMinimum() Minimum {
+	return Minimum{
+		operand: "min",
+	}
+}
+
+func (m Minimum) isOperation() {}
+
+func (m Minimum) symbol() string {
+	return m.operand
+}
+
+// clamping variant
+type Clamping struct {
+	operand string
+}
+
+func NewClamping() Clamping {
+	return Clamping{
+		operand: "clamp",
+	}
+}
+
+func (c Clamping) isOperation() {}
+
+func (c Clamping) symbol() string {
+	return c.operand
+}
+
+// standard normal cumulative distribution variant
+type NormalCdf struct {
+	operand string
+}
+
+func NewNormalCdf() NormalCdf {
+	return NormalCdf{
+		operand: "Phi",
+	}
+}
+
+func (n NormalCdf) isOperation() {}
+
+func (n NormalCdf) symbol() string {
+	return n.operand
+}
+
 // none variant
 type None struct {
 	operand string
@@ -114,6 +433,10 @@ func NewNone() None {
 
 func (n None) isOperation() {}
 
+func (n None) symbol() string {
+	return n.operand
+}
+
 // === END OF VARIANTS ===
 // === END OF OPERATIONS ARITHMETIC TYPE ===
 
@@ -142,6 +465,16 @@ type Value struct {
 	children cset
 	backward func()
 	op       operation
+
+	// graph mode (see BackwardPassGraph): the gradient as a Value
+	// and the derivative that builds it for the children
+	gradValue     *Value
+	backwardGraph func()
+
+	// ordered children and constant arguments of the operation (e.g. the exponent of Pow),
+	// used by compiled tapes to compute data again (see recompute)
+	operands [2]*Value
+	args     [2]float64
 }
 
 // Value constructor
@@ -180,6 +513,7 @@ func (v *Value) Add(other *Value) *Value {
 		op:       NewAddition(),
 		children: NewSet(v, other),
 		backward: func() {},
+		operands: [2]*Value{v, other},
 	}
 
 	// addition derivative
@@ -187,6 +521,10 @@ func (v *Value) Add(other *Value) *Value {
 		v.grad += 1 * out.grad
 		other.grad += 1 * out.grad
 	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue)
+		other.addGradValue(out.gradValue)
+	}
 
 	return &out
 }
@@ -198,6 +536,7 @@ func (v *Value) Mul(other *Value) *Value {
 		op:       NewMultiplication(),
 		children: NewSet(v, other),
 		backward: func() {},
+		operands: [2]*Value{v, other},
 	}
 
 	// multiplication derivative
@@ -205,6 +544,10 @@ func (v *Value) Mul(other *Value) *Value {
 		v.grad += other.data * out.grad
 		other.grad += v.data * out.grad
 	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(other))
+		other.addGradValue(out.gradValue.Mul(v))
+	}
 
 	return &out
 }
@@ -243,17 +586,22 @@ func (v *Value) Pow(exp float64) *Value {
 		op:       NewPower(),
 		children: NewSet(v, nil),
 		backward: func() {},
+		operands: [2]*Value{v},
+		args:     [2]float64{exp},
 	}
 
 	// power derivative
 	out.backward = func() {
 		v.grad += exp * math.Pow(v.data, exp-1) * out.grad
 	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(v.Pow(exp - 1).Mul(NewValue(exp))))
+	}
 
 	return &out
 }
 
-func (v *Value) Relu() Value {
+func (v *Value) Relu() *Value {
 	var d float64
 
 	if v.data <= 0.0 {
@@ -268,6 +616,7 @@ func (v *Value) Relu() Value {
 		op:       NewRelu(),
 		children: NewSet(v, nil),
 		backward: func() {},
+		operands: [2]*Value{v},
 	}
 
 	// ReLU derivative
@@ -278,8 +627,528 @@ func (v *Value) Relu() Value {
 			v.grad += 1 * out.grad
 		}
 	}
+	out.backwardGraph = func() {
+		if v.data >= 0 {
+			v.addGradValue(out.gradValue)
+		}
+	}
+
+	return &out
+}
+
+func (v *Value) Tanh() *Value {
+	t := math.Tanh(v.data)
+
+	out := Value{
+		data:     t,
+		grad:     0,
+		op:       NewTanh(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// tanh derivative
+	out.backward = func() {
+		v.grad += (1 - out.data*out.data) * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(NewValue(1.0).Sub(out.Pow(2))))
+	}
+
+	return &out
+}
+
+func (v *Value) Sigmoid() *Value {
+	s := sigmoid(v.data)
+
+	out := Value{
+		data:     s,
+		grad:     0,
+		op:       NewSigmoid(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// sigmoid derivative
+	out.backward = func() {
+		v.grad += out.data * (1 - out.data) * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(out.Mul(NewValue(1.0).Sub(&out))))
+	}
+
+	return &out
+}
+
+// slope is the multiplier applied to negative inputs (usually a small value like 0.01)
+func (v *Value) LeakyRelu(slope float64) *Value {
+	d := v.data
+	if v.data <= 0.0 {
+		d = slope * v.data
+	}
+
+	out := Value{
+		data:     d,
+		grad:     0,
+		op:       NewLeakyRelu(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+		args:     [2]float64{slope},
+	}
+
+	// leaky ReLU derivative
+	out.backward = func() {
+		if v.data <= 0 {
+			v.grad += slope * out.grad
+		} else {
+			v.grad += 1 * out.grad
+		}
+	}
+	out.backwardGraph = func() {
+		if v.data <= 0 {
+			v.addGradValue(out.gradValue.Mul(NewValue(slope)))
+		} else {
+			v.addGradValue(out.gradValue)
+		}
+	}
+
+	return &out
+}
+
+// alpha is the value the function saturates to (as -alpha) for large negative inputs
+func (v *Value) Elu(alpha float64) *Value {
+	d := v.data
+	if v.data <= 0.0 {
+		d = alpha * (math.Exp(v.data) - 1)
+	}
+
+	out := Value{
+		data:     d,
+		grad:     0,
+		op:       NewElu(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+		args:     [2]float64{alpha},
+	}
+
+	// ELU derivative
+	out.backward = func() {
+		if v.data <= 0 {
+			v.grad += (out.data + alpha) * out.grad
+		} else {
+			v.grad += 1 * out.grad
+		}
+	}
+	out.backwardGraph = func() {
+		if v.data <= 0 {
+			v.addGradValue(out.gradValue.Mul(out.Add(NewValue(alpha))))
+		} else {
+			v.addGradValue(out.gradValue)
+		}
+	}
+
+	return &out
+}
+
+// exact GELU, i.e. x * Phi(x) where Phi is the standard normal cumulative distribution
+func (v *Value) Gelu() *Value {
+	cdf := 0.5 * (1 + math.Erf(v.data/math.Sqrt2))
+
+	out := Value{
+		data:     v.data * cdf,
+		grad:     0,
+		op:       NewGelu(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// GELU derivative: Phi(x) + x * phi(x)
+	out.backward = func() {
+		cdf := 0.5 * (1 + math.Erf(v.data/math.Sqrt2))
+		pdf := math.Exp(-0.5*v.data*v.data) / math.Sqrt(2*math.Pi)
+		v.grad += (cdf + v.data*pdf) * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(v.normalCdf().Add(v.Mul(v.normalPdf()))))
+	}
+
+	return &out
+}
+
+func (v *Value) Softplus() *Value {
+	// log(1 + e^x) rewritten to avoid overflowing for large inputs
+	d := math.Log1p(math.Exp(-math.Abs(v.data))) + math.Max(v.data, 0)
+
+	out := Value{
+		data:     d,
+		grad:     0,
+		op:       NewSoftplus(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// softplus derivative (i.e. the sigmoid)
+	out.backward = func() {
+		v.grad += sigmoid(v.data) * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(v.Sigmoid()))
+	}
+
+	return &out
+}
+
+func (v *Value) Identity() *Value {
+	out := Value{
+		data:     v.data,
+		grad:     0,
+		op:       NewIdentity(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// identity derivative
+	out.backward = func() {
+		v.grad += 1 * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue)
+	}
 
-	return out
+	return &out
+}
+
+func (v *Value) Exp() *Value {
+	e := math.Exp(v.data)
+
+	out := Value{
+		data:     e,
+		grad:     0,
+		op:       NewExponential(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// exponential derivative
+	out.backward = func() {
+		v.grad += out.data * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(&out))
+	}
+
+	return &out
+}
+
+// natural logarithm
+func (v *Value) Log() *Value {
+	out := Value{
+		data:     math.Log(v.data),
+		grad:     0,
+		op:       NewLogarithm(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// logarithm derivative
+	out.backward = func() {
+		v.grad += (1 / v.data) * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(v.Pow(-1)))
+	}
+
+	return &out
+}
+
+func (v *Value) Sqrt() *Value {
+	r := math.Sqrt(v.data)
+
+	out := Value{
+		data:     r,
+		grad:     0,
+		op:       NewSquareRoot(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// square root derivative
+	out.backward = func() {
+		v.grad += (0.5 / out.data) * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(out.Pow(-1).Mul(NewValue(0.5))))
+	}
+
+	return &out
+}
+
+func (v *Value) Abs() *Value {
+	out := Value{
+		data:     math.Abs(v.data),
+		grad:     0,
+		op:       NewAbsolute(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// absolute value derivative (the subgradient 0 is used at 0)
+	out.backward = func() {
+		switch {
+		case v.data > 0:
+			v.grad += 1 * out.grad
+		case v.data < 0:
+			v.grad += -1 * out.grad
+		}
+	}
+	out.backwardGraph = func() {
+		switch {
+		case v.data > 0:
+			v.addGradValue(out.gradValue)
+		case v.data < 0:
+			v.addGradValue(out.gradValue.Neg())
+		}
+	}
+
+	return &out
+}
+
+func (v *Value) Sin() *Value {
+	out := Value{
+		data:     math.Sin(v.data),
+		grad:     0,
+		op:       NewSine(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// sine derivative
+	out.backward = func() {
+		v.grad += math.Cos(v.data) * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(v.Cos()))
+	}
+
+	return &out
+}
+
+func (v *Value) Cos() *Value {
+	out := Value{
+		data:     math.Cos(v.data),
+		grad:     0,
+		op:       NewCosine(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// cosine derivative
+	out.backward = func() {
+		v.grad += -math.Sin(v.data) * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(v.Sin().Neg()))
+	}
+
+	return &out
+}
+
+// the gradient flows only to the larger operand (to v when they are equal)
+func (v *Value) Max(other *Value) *Value {
+	out := Value{
+		data:     math.Max(v.data, other.data),
+		grad:     0,
+		op:       NewMaximum(),
+		children: NewSet(v, other),
+		backward: func() {},
+		operands: [2]*Value{v, other},
+	}
+
+	// maximum derivative
+	out.backward = func() {
+		if v.data >= other.data {
+			v.grad += 1 * out.grad
+		} else {
+			other.grad += 1 * out.grad
+		}
+	}
+	out.backwardGraph = func() {
+		if v.data >= other.data {
+			v.addGradValue(out.gradValue)
+		} else {
+			other.addGradValue(out.gradValue)
+		}
+	}
+
+	return &out
+}
+
+// the gradient flows only to the smaller operand (to v when they are equal)
+func (v *Value) Min(other *Value) *Value {
+	out := Value{
+		data:     math.Min(v.data, other.data),
+		grad:     0,
+		op:       NewMinimum(),
+		children: NewSet(v, other),
+		backward: func() {},
+		operands: [2]*Value{v, other},
+	}
+
+	// minimum derivative
+	out.backward = func() {
+		if v.data <= other.data {
+			v.grad += 1 * out.grad
+		} else {
+			other.grad += 1 * out.grad
+		}
+	}
+	out.backwardGraph = func() {
+		if v.data <= other.data {
+			v.addGradValue(out.gradValue)
+		} else {
+			other.addGradValue(out.gradValue)
+		}
+	}
+
+	return &out
+}
+
+// limits v to the [lo, hi] interval, no gradient flows when v is outside of it
+func (v *Value) Clamp(lo, hi float64) *Value {
+	out := Value{
+		data:     math.Min(math.Max(v.data, lo), hi),
+		grad:     0,
+		op:       NewClamping(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+		args:     [2]float64{lo, hi},
+	}
+
+	// clamp derivative
+	out.backward = func() {
+		if v.data >= lo && v.data <= hi {
+			v.grad += 1 * out.grad
+		}
+	}
+	out.backwardGraph = func() {
+		if v.data >= lo && v.data <= hi {
+			v.addGradValue(out.gradValue)
+		}
+	}
+
+	return &out
+}
+
+// standard normal cumulative distribution, used by the derivative of GELU in graph mode
+func (v *Value) normalCdf() *Value {
+	out := Value{
+		data:     0.5 * (1 + math.Erf(v.data/math.Sqrt2)),
+		grad:     0,
+		op:       NewNormalCdf(),
+		children: NewSet(v, nil),
+		backward: func() {},
+		operands: [2]*Value{v},
+	}
+
+	// normal cumulative distribution derivative (i.e. the normal density)
+	out.backward = func() {
+		v.grad += math.Exp(-0.5*v.data*v.data) / math.Sqrt(2*math.Pi) * out.grad
+	}
+	out.backwardGraph = func() {
+		v.addGradValue(out.gradValue.Mul(v.normalPdf()))
+	}
+
+	return &out
+}
+
+// standard normal density, built out of the other operations
+func (v *Value) normalPdf() *Value {
+	return v.Pow(2).Mul(NewValue(-0.5)).Exp().Mul(NewValue(1 / math.Sqrt(2*math.Pi)))
+}
+
+// computes data again from the current data of the operands, the same way the operation did
+// it is what compiled tapes run for each node, so that building a graph does not need a closure for it
+func (v *Value) recompute() {
+	a, b := v.operands[0], v.operands[1]
+
+	switch v.op.(type) {
+	case Addition:
+		v.data = a.data + b.data
+	case Multiplication:
+		v.data = a.data * b.data
+	case Power:
+		v.data = math.Pow(a.data, v.args[0])
+	case Relu:
+		v.data = math.Max(a.data, 0.0)
+	case Tanh:
+		v.data = math.Tanh(a.data)
+	case Sigmoid:
+		v.data = sigmoid(a.data)
+	case LeakyRelu:
+		v.data = a.data
+		if a.data <= 0.0 {
+			v.data = v.args[0] * a.data
+		}
+	case Elu:
+		v.data = a.data
+		if a.data <= 0.0 {
+			v.data = v.args[0] * (math.Exp(a.data) - 1)
+		}
+	case Gelu:
+		v.data = a.data * 0.5 * (1 + math.Erf(a.data/math.Sqrt2))
+	case Softplus:
+		v.data = math.Log1p(math.Exp(-math.Abs(a.data))) + math.Max(a.data, 0)
+	case Identity:
+		v.data = a.data
+	case Exponential:
+		v.data = math.Exp(a.data)
+	case Logarithm:
+		v.data = math.Log(a.data)
+	case SquareRoot:
+		v.data = math.Sqrt(a.data)
+	case Absolute:
+		v.data = math.Abs(a.data)
+	case Sine:
+		v.data = math.Sin(a.data)
+	case Cosine:
+		v.data = math.Cos(a.data)
+	case Maximum:
+		v.data = math.Max(a.data, b.data)
+	case Minimum:
+		v.data = math.Min(a.data, b.data)
+	case Clamping:
+		v.data = math.Min(math.Max(a.data, v.args[0]), v.args[1])
+	case NormalCdf:
+		v.data = 0.5 * (1 + math.Erf(a.data/math.Sqrt2))
+	case None:
+		// leaves (placeholders and parameters) keep their data
+	default:
+		panic(fmt.Sprintf("gograd: cannot recompute operation %s", v.op.symbol()))
+	}
+}
+
+// numerically stable logistic function
+func sigmoid(x float64) float64 {
+	if x >= 0 {
+		return 1 / (1 + math.Exp(-x))
+	}
+	e := math.Exp(x)
+
+	return e / (1 + e)
 }
 
 func (v *Value) BackwardPass() {
@@ -295,13 +1164,117 @@ func (v *Value) BackwardPass() {
 	}
 }
 
+// BackwardPassGraph is like BackwardPass, but the gradient of v with respect to each node
+// of its graph is built as a graph of Values itself and can be read with GradValue
+// such a gradient can be differentiated again (e.g. calling BackwardPass on it) to get second derivatives
+// the float gradients are left untouched, while the previous gradient Values are replaced
+func (v *Value) BackwardPassGraph() {
+	visited := map[*Value]bool{}
+	tpOrder := []*Value{}
+
+	topologicalSort(v, &visited, &tpOrder)
+	for _, node := range tpOrder {
+		node.gradValue = nil
+	}
+
+	v.gradValue = NewValue(1.0)
+	for _, node := range reverse(tpOrder) {
+		// nodes without a gradient do not affect v, and neither do their children through them
+		if node.gradValue == nil || len(node.children) == 0 {
+			continue
+		}
+		if node.backwardGraph == nil {
+			panic(fmt.Sprintf("gograd: operation %s cannot build its gradient as a graph", node.op.symbol()))
+		}
+		node.backwardGraph()
+	}
+}
+
+// GradValue is the gradient computed by the last BackwardPassGraph
+// (a constant 0 when v does not affect the value it was called on)
+func (v *Value) GradValue() *Value {
+	if v.gradValue == nil {
+		return NewValue(0.0)
+	}
+
+	return v.gradValue
+}
+
+// sums g to the gradient Value, without modifying the nodes already built
+func (v *Value) addGradValue(g *Value) {
+	if v.gradValue == nil {
+		v.gradValue = g
+	} else {
+		v.gradValue = v.gradValue.Add(g)
+	}
+}
+
+// HessianVectorProduct returns H·vec, where H is the matrix of the second derivatives of f at inputs,
+// computing the gradient of f as a graph and then differentiating its dot product with vec
+// (without ever building H)
+func HessianVectorProduct(f func([]*Value) *Value, inputs []float64, vec []float64) ([]float64, error) {
+	if len(inputs) != len(vec) {
+		return nil, fmt.Errorf("gograd: %d inputs but a vector of %d", len(inputs), len(vec))
+	}
+
+	xs := make([]*Value, len(inputs))
+	for i, x := range inputs {
+		xs[i] = NewValue(x)
+	}
+	f(xs).BackwardPassGraph()
+
+	dot := NewValue(0.0)
+	for i, x := range xs {
+		dot = dot.Add(x.GradValue().Mul(NewValue(vec[i])))
+	}
+	dot.BackwardPass()
+
+	hv := make([]float64, len(xs))
+	for i, x := range xs {
+		hv[i] = x.GetGrad()
+	}
+
+	return hv, nil
+}
+
+// post-order depth first visit, every node is appended after all of its children
+// an explicit stack is used instead of recursion so that very deep graphs
+// (e.g. long chains of additions) do not grow the goroutine stack
 func topologicalSort(node *Value, visited *map[*Value]bool, tpOrder *[]*Value) {
-	if _, ok := (*visited)[node]; !ok {
-		(*visited)[node] = true
-		for child := range node.children {
-			topologicalSort(child, visited, tpOrder)
+	// a node on the stack together with the children still to be visited
+	type frame struct {
+		node     *Value
+		children []*Value
+	}
+	push := func(stack []frame, n *Value) []frame {
+		(*visited)[n] = true
+		children := make([]*Value, 0, len(n.children))
+		for child := range n.children {
+			children = append(children, child)
+		}
+		return append(stack, frame{node: n, children: children})
+	}
+
+	if _, ok := (*visited)[node]; ok {
+		return
+	}
+	stack := push(nil, node)
+
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+
+		// all children done, the node can be appended
+		if len(top.children) == 0 {
+			*tpOrder = append(*tpOrder, top.node)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		child := top.children[0]
+		top.children = top.children[1:]
+		if _, ok := (*visited)[child]; !ok {
+			stack = push(stack, child)
 		}
-		*tpOrder = append(*tpOrder, node)
 	}
 }
 
@@ -287,3 +287,197 @@ func TestReversedTopological(t *testing.T) {
 		}
 	}
 }
+
+func TestTopologicalSortShared(t *testing.T) {
+	// a node used twice must be visited once and come after all of its children
+	a := NewValue(2.0)
+	b := a.Mul(a)
+	c := b.Add(a)
+	d := c.Mul(b)
+
+	visited := map[*Value]bool{}
+	tpOrder := []*Value{}
+	topologicalSort(d, &visited, &tpOrder)
+
+	if len(tpOrder) != 4 {
+		t.Fatalf("The topological order has the wrong number of nodes, got:%d, want:%d", len(tpOrder), 4)
+	}
+	position := map[*Value]int{}
+	for idx, node := range tpOrder {
+		position[node] = idx
+	}
+	for _, node := range tpOrder {
+		for child := range node.children {
+			if position[child] > position[node] {
+				t.Errorf("Node %f comes before its child %f", node.GetData(), child.GetData())
+			}
+		}
+	}
+}
+
+func TestBackwardPassDeepChain(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the million nodes chain in short mode")
+	}
+
+	// a million nodes in a line, like a long sum of losses built with loss = loss.Add(...)
+	x := NewValue(3.0)
+	y := x
+	for i := 0; i < 1000000; i++ {
+		y = y.Identity()
+	}
+	y.BackwardPass()
+
+	if x.GetGrad() != 1.0 {
+		t.Errorf("Gradient through the chain was incorrect, got:%f, want:%f", x.GetGrad(), 1.0)
+	}
+}
+
+func TestActivations(t *testing.T) {
+	// data table for test (n is the activation value, g its derivative)
+	activationTestTable := []struct {
+		name string
+		f    func(*Value) *Value
+		x    float64
+		n    float64
+		g    float64
+	}{
+		{"Tanh", (*Value).Tanh, -1.013451871, -0.767185972, 0.411425684},
+		{"Tanh", (*Value).Tanh, 0.762946109, 0.642809003, 0.586796586},
+		{"Sigmoid", (*Value).Sigmoid, -1.013451871, 0.266304858, 0.195386580},
+		{"Sigmoid", (*Value).Sigmoid, 0.762946109, 0.681993024, 0.216878539},
+		{"LeakyRelu", func(v *Value) *Value { return v.LeakyRelu(0.01) }, -1.013451871, -0.010134519, 0.010000000},
+		{"LeakyRelu", func(v *Value) *Value { return v.LeakyRelu(0.01) }, 0.762946109, 0.762946109, 1.000000000},
+		{"Elu", func(v *Value) *Value { return v.Elu(1.0) }, -1.013451871, -0.637036090, 0.362963910},
+		{"Elu", func(v *Value) *Value { return v.Elu(1.0) }, 0.762946109, 0.762946109, 1.000000000},
+		{"Gelu", (*Value).Gelu, -1.013451871, -0.157512906, -0.086504951},
+		{"Gelu", (*Value).Gelu, 0.762946109, 0.593001566, 1.004764800},
+		{"Softplus", (*Value).Softplus, -1.013451871, 0.309661674, 0.266304858},
+		{"Softplus", (*Value).Softplus, 0.762946109, 1.145681959, 0.681993024},
+		{"Identity", (*Value).Identity, -1.013451871, -1.013451871, 1.000000000},
+	}
+
+	for _, table := range activationTestTable {
+		a := NewValue(table.x)
+		out := table.f(a)
+		out.BackwardPass()
+
+		if math.Round(out.data*1_000_000_000)/1_000_000_000 != math.Round(table.n*1_000_000_000)/1_000_000_000 {
+			t.Errorf(
+				"%s of Value type was incorrect, got: %0.9f, want: %0.9f.",
+				table.name,
+				math.Round(out.data*1_000_000_000)/1_000_000_000,
+				math.Round(table.n*1_000_000_000)/1_000_000_000,
+			)
+		}
+		if math.Round(a.grad*1_000_000_000)/1_000_000_000 != math.Round(table.g*1_000_000_000)/1_000_000_000 {
+			t.Errorf(
+				"%s derivative of Value type was incorrect, got: %0.9f, want: %0.9f.",
+				table.name,
+				math.Round(a.grad*1_000_000_000)/1_000_000_000,
+				math.Round(table.g*1_000_000_000)/1_000_000_000,
+			)
+		}
+	}
+}
+
+// central finite difference approximation of the derivative of f at x
+func finiteDiff(f func(float64) float64, x float64) float64 {
+	h := 1e-6
+
+	return (f(x+h) - f(x-h)) / (2 * h)
+}
+
+func TestElementaryOps(t *testing.T) {
+	// data table for test, each op is checked against its float64 counterpart
+	// and its derivative against the finite difference approximation
+	elementaryTestTable := []struct {
+		name string
+		f    func(*Value) *Value
+		ref  func(float64) float64
+		x    float64
+	}{
+		{"Exp", (*Value).Exp, math.Exp, -1.013451871},
+		{"Exp", (*Value).Exp, math.Exp, 2.006839573},
+		{"Log", (*Value).Log, math.Log, 0.002774926},
+		{"Log", (*Value).Log, math.Log, 3.119647378},
+		{"Sqrt", (*Value).Sqrt, math.Sqrt, 0.110349676},
+		{"Sqrt", (*Value).Sqrt, math.Sqrt, 7.342752101},
+		{"Abs", (*Value).Abs, math.Abs, -1.013451871},
+		{"Abs", (*Value).Abs, math.Abs, 0.762946109},
+		{"Sin", (*Value).Sin, math.Sin, -1.013451871},
+		{"Sin", (*Value).Sin, math.Sin, 2.006839573},
+		{"Cos", (*Value).Cos, math.Cos, -1.013451871},
+		{"Cos", (*Value).Cos, math.Cos, 2.006839573},
+		{"Tanh", (*Value).Tanh, math.Tanh, -1.013451871},
+		{"Tanh", (*Value).Tanh, math.Tanh, 0.762946109},
+		{
+			"Max",
+			func(v *Value) *Value { return v.Max(NewValue(0.5)) },
+			func(x float64) float64 { return math.Max(x, 0.5) },
+			-1.013451871,
+		},
+		{
+			"Max",
+			func(v *Value) *Value { return v.Max(NewValue(0.5)) },
+			func(x float64) float64 { return math.Max(x, 0.5) },
+			0.762946109,
+		},
+		{
+			"Min",
+			func(v *Value) *Value { return v.Min(NewValue(0.5)) },
+			func(x float64) float64 { return math.Min(x, 0.5) },
+			-1.013451871,
+		},
+		{
+			"Min",
+			func(v *Value) *Value { return v.Min(NewValue(0.5)) },
+			func(x float64) float64 { return math.Min(x, 0.5) },
+			0.762946109,
+		},
+		{
+			"Clamp",
+			func(v *Value) *Value { return v.Clamp(-1.0, 1.0) },
+			func(x float64) float64 { return math.Min(math.Max(x, -1.0), 1.0) },
+			-1.013451871,
+		},
+		{
+			"Clamp",
+			func(v *Value) *Value { return v.Clamp(-1.0, 1.0) },
+			func(x float64) float64 { return math.Min(math.Max(x, -1.0), 1.0) },
+			0.762946109,
+		},
+	}
+
+	for _, table := range elementaryTestTable {
+		a := NewValue(table.x)
+		out := table.f(a)
+		out.BackwardPass()
+
+		if out.data != table.ref(table.x) {
+			t.Errorf("%s of Value type was incorrect at x=%f, got: %0.9f, want: %0.9f.", table.name, table.x, out.data, table.ref(table.x))
+		}
+
+		want := finiteDiff(table.ref, table.x)
+		if math.Abs(a.grad-want) > 1e-6*math.Max(1, math.Abs(want)) {
+			t.Errorf("%s derivative of Value type was incorrect at x=%f, got: %0.9f, want: %0.9f.", table.name, table.x, a.grad, want)
+		}
+	}
+}
+
+func TestMaxMinOtherOperand(t *testing.T) {
+	// the gradient of the binary ops must reach the other operand when it is the selected one
+	a := NewValue(-1.0)
+	b := NewValue(2.0)
+	a.Max(b).BackwardPass()
+	if a.grad != 0.0 || b.grad != 1.0 {
+		t.Errorf("Max gradients were incorrect, got: (%f, %f), want: (%f, %f).", a.grad, b.grad, 0.0, 1.0)
+	}
+
+	c := NewValue(3.0)
+	d := NewValue(2.0)
+	c.Min(d).BackwardPass()
+	if c.grad != 0.0 || d.grad != 1.0 {
+		t.Errorf("Min gradients were incorrect, got: (%f, %f), want: (%f, %f).", c.grad, d.grad, 0.0, 1.0)
+	}
+}
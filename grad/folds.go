@@ -0,0 +1,206 @@
+package grad
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Split is a partition of a dataset into training and holdout samples, as indices of the samples
+type Split struct {
+	Train   []int
+	Holdout []int
+}
+
+// Splitter generates the splits used by cross validation for a dataset with the given labels
+// every sample ends up in the holdout set of exactly one split per repetition
+// (or in none of them, for a single holdout split)
+type Splitter interface {
+	Splits(labels []float64) []Split
+}
+
+// KFold divides the samples in k folds of (almost) the same size, each one used as holdout once
+// when the samples are not a multiple of k the first folds get one more sample each
+type KFold struct {
+	k       int
+	repeats int
+	rng     *rand.Rand // nil when samples are not shuffled
+}
+
+// NewKFold keeps the samples in their order, i.e. the first fold holds the first samples and so on
+func NewKFold(k int) *KFold {
+	return &KFold{k: k, repeats: 1}
+}
+
+// NewShuffledKFold shuffles the samples before dividing them in folds
+func NewShuffledKFold(k int, seed int64) *KFold {
+	return &KFold{k: k, repeats: 1, rng: rand.New(rand.NewSource(seed))}
+}
+
+// NewRepeatedKFold runs shuffled k-fold repeats times, each time with a different shuffle
+func NewRepeatedKFold(k int, repeats int, seed int64) *KFold {
+	return &KFold{k: k, repeats: repeats, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (kf *KFold) Splits(labels []float64) []Split {
+	checkFolds(kf.k, len(labels))
+
+	var splits []Split
+	for r := 0; r < kf.repeats; r++ {
+		order := indices(len(labels))
+		if kf.rng != nil {
+			kf.rng.Shuffle(len(order), func(i, j int) {
+				order[i], order[j] = order[j], order[i]
+			})
+		}
+		splits = append(splits, foldSplits(chunk(order, kf.k))...)
+	}
+
+	return splits
+}
+
+// StratifiedKFold is a shuffled k-fold where each fold keeps the same proportion
+// of positive (label > 0) and negative samples of the whole dataset
+type StratifiedKFold struct {
+	k   int
+	rng *rand.Rand
+}
+
+func NewStratifiedKFold(k int, seed int64) *StratifiedKFold {
+	return &StratifiedKFold{k: k, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (skf *StratifiedKFold) Splits(labels []float64) []Split {
+	checkFolds(skf.k, len(labels))
+
+	var pos, neg []int
+	for idx, l := range labels {
+		if l > 0 {
+			pos = append(pos, idx)
+		} else {
+			neg = append(neg, idx)
+		}
+	}
+
+	// deal the samples of each class to the folds like a deck of cards
+	// continuing from the fold where the previous class stopped so sizes stay balanced
+	folds := make([][]int, skf.k)
+	next := 0
+	for _, class := range [][]int{pos, neg} {
+		skf.rng.Shuffle(len(class), func(i, j int) {
+			class[i], class[j] = class[j], class[i]
+		})
+		for _, idx := range class {
+			folds[next] = append(folds[next], idx)
+			next = (next + 1) % skf.k
+		}
+	}
+
+	return foldSplits(folds)
+}
+
+// LeaveOneOut uses each sample, alone, as holdout once
+type LeaveOneOut struct{}
+
+func NewLeaveOneOut() LeaveOneOut {
+	return LeaveOneOut{}
+}
+
+func (LeaveOneOut) Splits(labels []float64) []Split {
+	return NewKFold(len(labels)).Splits(labels)
+}
+
+// HoldoutSplit puts a random fraction of the samples in the holdout set and the rest in the training one
+type HoldoutSplit struct {
+	fraction float64
+	rng      *rand.Rand
+}
+
+func NewHoldoutSplit(fraction float64, seed int64) *HoldoutSplit {
+	if fraction <= 0 || fraction >= 1 {
+		panic(fmt.Sprintf("gograd: holdout fraction must be between 0 and 1, got %f", fraction))
+	}
+
+	return &HoldoutSplit{fraction: fraction, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (hs *HoldoutSplit) Splits(labels []float64) []Split {
+	if len(labels) < 2 {
+		panic(fmt.Sprintf("gograd: cannot divide %d samples in a training and a holdout set", len(labels)))
+	}
+
+	order := indices(len(labels))
+	hs.rng.Shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+
+	// at least one sample in each set
+	n := int(hs.fraction*float64(len(order)) + 0.5)
+	if n < 1 {
+		n = 1
+	}
+	if n > len(order)-1 {
+		n = len(order) - 1
+	}
+
+	return []Split{{Train: order[n:], Holdout: order[:n]}}
+}
+
+// splits using each fold as holdout and the others for training
+func foldSplits(folds [][]int) []Split {
+	splits := make([]Split, len(folds))
+
+	for i := range folds {
+		splits[i].Holdout = folds[i]
+		for j := range folds {
+			if j != i {
+				splits[i].Train = append(splits[i].Train, folds[j]...)
+			}
+		}
+	}
+
+	return splits
+}
+
+// divides s in k contiguous chunks, the first len(s)%k of them one element longer
+func chunk(s []int, k int) [][]int {
+	chunks := make([][]int, k)
+
+	start := 0
+	for i := range chunks {
+		size := len(s) / k
+		if i < len(s)%k {
+			size++
+		}
+		chunks[i] = s[start : start+size]
+		start += size
+	}
+
+	return chunks
+}
+
+func indices(n int) []int {
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+
+	return idx
+}
+
+func checkFolds(k int, n int) {
+	if k < 2 || k > n {
+		panic(fmt.Sprintf("gograd: cannot divide %d samples in %d folds", n, k))
+	}
+}
+
+// samples and labels at the given indices
+func subset(data [][]float64, labels []float64, idx []int) ([][]float64, []float64) {
+	d := make([][]float64, len(idx))
+	l := make([]float64, len(idx))
+	for i, j := range idx {
+		d[i] = data[j]
+		l[i] = labels[j]
+	}
+
+	return d, l
+}
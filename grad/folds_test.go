@@ -0,0 +1,153 @@
+package grad
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// checks that every split uses all the samples and that, in each repetition,
+// every sample is in the holdout set of exactly one split
+func checkSplits(t *testing.T, name string, splits []Split, n int, repeats int) {
+	if len(splits)%repeats != 0 {
+		t.Fatalf("%s generated %d splits, not a multiple of %d repetitions", name, len(splits), repeats)
+	}
+	perRepeat := len(splits) / repeats
+
+	for r := 0; r < repeats; r++ {
+		held := make([]int, n)
+		for _, s := range splits[r*perRepeat : (r+1)*perRepeat] {
+			if len(s.Train)+len(s.Holdout) != n {
+				t.Errorf("%s split does not use every sample, got:%d, want:%d", name, len(s.Train)+len(s.Holdout), n)
+			}
+			seen := map[int]bool{}
+			for _, idx := range append(append([]int{}, s.Train...), s.Holdout...) {
+				if seen[idx] {
+					t.Errorf("%s split uses sample %d twice", name, idx)
+				}
+				seen[idx] = true
+			}
+			for _, idx := range s.Holdout {
+				held[idx]++
+			}
+		}
+		for idx, h := range held {
+			if h != 1 {
+				t.Errorf("%s repetition %d held out sample %d %d times, want:1", name, r, idx, h)
+			}
+		}
+	}
+}
+
+func TestKFold(t *testing.T) {
+	labels := GetLabels()[:23]
+
+	splits := NewKFold(5).Splits(labels)
+	checkSplits(t, "KFold", splits, len(labels), 1)
+
+	// 23 samples in 5 folds: the first 3 folds get one more sample
+	for idx, want := range []int{5, 5, 5, 4, 4} {
+		if len(splits[idx].Holdout) != want {
+			t.Errorf("KFold fold %d has the wrong size, got:%d, want:%d", idx, len(splits[idx].Holdout), want)
+		}
+	}
+	if !reflect.DeepEqual(splits[0].Holdout, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("KFold should keep the samples in order, got:%v", splits[0].Holdout)
+	}
+
+	checkSplits(t, "ShuffledKFold", NewShuffledKFold(5, 1).Splits(labels), len(labels), 1)
+	checkSplits(t, "RepeatedKFold", NewRepeatedKFold(5, 3, 1).Splits(labels), len(labels), 3)
+	checkSplits(t, "StratifiedKFold", NewStratifiedKFold(5, 1).Splits(labels), len(labels), 1)
+	checkSplits(t, "LeaveOneOut", NewLeaveOneOut().Splits(labels), len(labels), 1)
+}
+
+func TestSplittersSeed(t *testing.T) {
+	labels := GetLabels()
+
+	for _, tc := range []struct {
+		name string
+		a, b Splitter
+	}{
+		{"ShuffledKFold", NewShuffledKFold(4, 7), NewShuffledKFold(4, 7)},
+		{"RepeatedKFold", NewRepeatedKFold(4, 2, 7), NewRepeatedKFold(4, 2, 7)},
+		{"StratifiedKFold", NewStratifiedKFold(4, 7), NewStratifiedKFold(4, 7)},
+		{"HoldoutSplit", NewHoldoutSplit(0.2, 7), NewHoldoutSplit(0.2, 7)},
+	} {
+		if !reflect.DeepEqual(tc.a.Splits(labels), tc.b.Splits(labels)) {
+			t.Errorf("%s with the same seed generated different splits", tc.name)
+		}
+	}
+
+	// repetitions must not be the same shuffle
+	splits := NewRepeatedKFold(4, 2, 7).Splits(labels)
+	if reflect.DeepEqual(splits[0].Holdout, splits[4].Holdout) {
+		t.Errorf("RepeatedKFold used the same shuffle for two repetitions")
+	}
+}
+
+func TestStratifiedKFold(t *testing.T) {
+	labels := GetLabels()
+
+	positives := 0.0
+	for _, l := range labels {
+		if l > 0 {
+			positives++
+		}
+	}
+	ratio := positives / float64(len(labels))
+
+	for idx, s := range NewStratifiedKFold(5, 3).Splits(labels) {
+		pos := 0.0
+		for _, i := range s.Holdout {
+			if labels[i] > 0 {
+				pos++
+			}
+		}
+		// each fold can be off by at most one sample of each class
+		if math.Abs(pos/float64(len(s.Holdout))-ratio) > 1.0/float64(len(s.Holdout)) {
+			t.Errorf("StratifiedKFold fold %d does not keep the class ratio, got:%f, want:%f", idx, pos/float64(len(s.Holdout)), ratio)
+		}
+	}
+}
+
+func TestHoldoutSplit(t *testing.T) {
+	labels := GetLabels()
+
+	splits := NewHoldoutSplit(0.25, 1).Splits(labels)
+	if len(splits) != 1 {
+		t.Fatalf("HoldoutSplit generated the wrong number of splits, got:%d, want:%d", len(splits), 1)
+	}
+	if len(splits[0].Holdout) != 25 || len(splits[0].Train) != 75 {
+		t.Errorf("HoldoutSplit has the wrong sizes, got:%d/%d, want:%d/%d", len(splits[0].Train), len(splits[0].Holdout), 75, 25)
+	}
+
+	seen := map[int]bool{}
+	for _, idx := range append(append([]int{}, splits[0].Train...), splits[0].Holdout...) {
+		seen[idx] = true
+	}
+	if len(seen) != len(labels) {
+		t.Errorf("HoldoutSplit does not use every sample exactly once, got:%d distinct, want:%d", len(seen), len(labels))
+	}
+}
+
+func TestSplitterPanics(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		f    func()
+	}{
+		{"too many folds", func() { NewKFold(11).Splits(GetLabels()[:10]) }},
+		{"one fold", func() { NewStratifiedKFold(1, 1).Splits(GetLabels()) }},
+		{"holdout fraction", func() { NewHoldoutSplit(1.0, 1) }},
+		{"holdout of one sample", func() { NewHoldoutSplit(0.5, 1).Splits(GetLabels()[:1]) }},
+		{"holdout of no samples", func() { NewHoldoutSplit(0.5, 1).Splits(nil) }},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s should have panicked", tc.name)
+				}
+			}()
+			tc.f()
+		}()
+	}
+}
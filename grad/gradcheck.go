@@ -0,0 +1,131 @@
+package grad
+
+import (
+	"fmt"
+	"math"
+)
+
+// GradCheckResult compares the gradient of a single input computed by BackwardPass
+// with its central finite difference approximation
+type GradCheckResult struct {
+	Analytic float64
+	Numeric  float64
+	RelError float64
+	OK       bool // whether the relative error is within the tolerance
+}
+
+// GradCheck evaluates f at inputs, backpropagates and compares the gradient of each input
+// with (f(x+eps) - f(x-eps)) / 2eps, where only that input is moved
+// a result is returned for each input, and an error when any of them is not within tol
+func GradCheck(f func([]*Value) *Value, inputs []float64, eps float64, tol float64) ([]GradCheckResult, error) {
+	eval := func(xs []float64) ([]*Value, *Value) {
+		vs := make([]*Value, len(xs))
+		for i, x := range xs {
+			vs[i] = NewValue(x)
+		}
+		return vs, f(vs)
+	}
+
+	vs, out := eval(inputs)
+	out.BackwardPass()
+	analytic := make([]float64, len(vs))
+	for i, v := range vs {
+		analytic[i] = v.GetGrad()
+	}
+
+	numeric := make([]float64, len(inputs))
+	moved := make([]float64, len(inputs))
+	for i := range inputs {
+		copy(moved, inputs)
+		moved[i] = inputs[i] + eps
+		_, plus := eval(moved)
+		moved[i] = inputs[i] - eps
+		_, minus := eval(moved)
+		numeric[i] = (plus.GetData() - minus.GetData()) / (2 * eps)
+	}
+
+	return compareGrads(analytic, numeric, tol)
+}
+
+// ModelGradCheck does the same as GradCheck for every parameter of m (in the order of Params),
+// the function being the mean of lossFunc over the given samples
+// m must have a single output, its parameters are restored and its gradients zeroed when done
+func ModelGradCheck(
+	m *Model,
+	lossFunc func(*Value, float64) *Value,
+	inputs [][]float64,
+	labels []float64,
+	eps float64,
+	tol float64,
+) ([]GradCheckResult, error) {
+	if len(inputs) != len(labels) {
+		return nil, fmt.Errorf("gograd: %d inputs but %d labels", len(inputs), len(labels))
+	}
+
+	meanLoss := func() (*Value, error) {
+		loss := NewValue(0.0)
+		for idx, inp := range inputs {
+			out, err := m.FeedForward(inp)
+			if err != nil {
+				return nil, err
+			}
+			if len(out) != 1 {
+				return nil, fmt.Errorf("gograd: gradient check needs a model with a single output, got %d", len(out))
+			}
+			loss = loss.Add(lossFunc(out[0], labels[idx]))
+		}
+		return loss.Div(len(inputs)), nil
+	}
+
+	params := m.Params()
+	m.ZeroGrad()
+	defer m.ZeroGrad()
+
+	loss, err := meanLoss()
+	if err != nil {
+		return nil, err
+	}
+	loss.BackwardPass()
+	analytic := make([]float64, len(params))
+	for i, p := range params {
+		analytic[i] = p.GetGrad()
+	}
+
+	// parameters are moved in place, one at a time
+	numeric := make([]float64, len(params))
+	for i, p := range params {
+		orig := p.data
+
+		p.data = orig + eps
+		plus, _ := meanLoss()
+		p.data = orig - eps
+		minus, _ := meanLoss()
+		p.data = orig
+
+		numeric[i] = (plus.GetData() - minus.GetData()) / (2 * eps)
+	}
+
+	return compareGrads(analytic, numeric, tol)
+}
+
+// relative errors are computed as |a - n| / max(|a|, |n|), where the denominator is at least
+// gradCheckFloor so that two gradients both close to 0 are not reported as different
+const gradCheckFloor = 1e-8
+
+func compareGrads(analytic []float64, numeric []float64, tol float64) ([]GradCheckResult, error) {
+	results := make([]GradCheckResult, len(analytic))
+	var err error
+
+	for i := range analytic {
+		a, n := analytic[i], numeric[i]
+		rel := math.Abs(a-n) / math.Max(math.Max(math.Abs(a), math.Abs(n)), gradCheckFloor)
+		results[i] = GradCheckResult{Analytic: a, Numeric: n, RelError: rel, OK: rel <= tol}
+
+		// report the first failure
+		if !results[i].OK && err == nil {
+			err = fmt.Errorf("gograd: gradient check failed at index %d, analytic %g, numeric %g, relative error %g", i, a, n, rel)
+		}
+	}
+
+	return results, err
+}
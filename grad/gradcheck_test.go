@@ -0,0 +1,77 @@
+package grad
+
+import (
+	"testing"
+)
+
+func TestGradCheck(t *testing.T) {
+	gradCheckTestTable := []struct {
+		name   string
+		f      func([]*Value) *Value
+		inputs []float64
+	}{
+		{"polynomial", func(x []*Value) *Value { return x[0].Mul(x[1]).Add(x[0].Pow(3)).Sub(x[1].Div(x[0])) }, []float64{1.3, -0.7}},
+		{"transcendental", func(x []*Value) *Value { return x[0].Exp().Mul(x[1].Sin()).Add(x[1].Log()) }, []float64{0.4, 2.1}},
+		{"activations", func(x []*Value) *Value { return x[0].Tanh().Mul(x[1].Sigmoid()).Add(x[0].Gelu()).Add(x[1].Softplus()) }, []float64{-0.3, 0.8}},
+		{"min max", func(x []*Value) *Value { return x[0].Max(x[1]).Mul(x[0].Min(x[1])).Add(x[2].Clamp(-1, 1)) }, []float64{0.5, 1.5, 0.2}},
+		{"unused input", func(x []*Value) *Value { return x[0].Sqrt() }, []float64{2.0, 5.0}},
+	}
+
+	for _, tc := range gradCheckTestTable {
+		results, err := GradCheck(tc.f, tc.inputs, 1e-6, 1e-5)
+		if err != nil {
+			t.Errorf("Gradient check of %s failed: %v", tc.name, err)
+		}
+		if len(results) != len(tc.inputs) {
+			t.Errorf("Gradient check of %s returned the wrong number of results, got:%d, want:%d", tc.name, len(results), len(tc.inputs))
+		}
+	}
+}
+
+func TestGradCheckWrongBackward(t *testing.T) {
+	// square with a derivative of x instead of 2x
+	badSquare := func(x []*Value) *Value {
+		out := x[0].Mul(x[0])
+		out.backward = func() {
+			x[0].grad += x[0].data * out.grad
+		}
+		return out
+	}
+
+	results, err := GradCheck(badSquare, []float64{3.0}, 1e-6, 1e-5)
+	if err == nil {
+		t.Fatalf("Gradient check of a wrong backward should have failed")
+	}
+	if results[0].OK || results[0].Analytic != 3.0 {
+		t.Errorf("Gradient check reported the wrong result, got:%+v", results[0])
+	}
+}
+
+func TestModelGradCheck(t *testing.T) {
+	m := NewModel(2, []int{3, 3, 1}, WithActivations(ActTanh, ActSigmoid, ActIdentity), WithSeed(11))
+	inputs, labels := GetInputs()[:5], GetLabels()[:5]
+	before := make([]float64, len(m.Params()))
+	for i, p := range m.Params() {
+		before[i] = p.GetData()
+	}
+
+	results, err := ModelGradCheck(m, MSE, inputs, labels, 1e-6, 1e-5)
+	if err != nil {
+		t.Errorf("Gradient check of the model failed: %v", err)
+	}
+	if len(results) != len(m.Params()) {
+		t.Errorf("Gradient check returned the wrong number of results, got:%d, want:%d", len(results), len(m.Params()))
+	}
+	for i, p := range m.Params() {
+		if p.GetData() != before[i] || p.GetGrad() != 0.0 {
+			t.Errorf("Gradient check did not restore parameter %d, got:%f (grad %f), want:%f", i, p.GetData(), p.GetGrad(), before[i])
+		}
+	}
+
+	if _, err := ModelGradCheck(m, MSE, inputs, labels[:2], 1e-6, 1e-5); err == nil {
+		t.Errorf("Gradient check with a different number of inputs and labels should have failed")
+	}
+	if _, err := ModelGradCheck(NewModel(2, []int{2}), MSE, inputs, labels, 1e-6, 1e-5); err == nil {
+		t.Errorf("Gradient check of a model with two outputs should have failed")
+	}
+}
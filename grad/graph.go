@@ -0,0 +1,53 @@
+package grad
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// WriteDot writes the computational graph ending in root to w as a Graphviz DOT document
+// each Value becomes a record node showing its data and grad, while each operation
+// becomes a separate node linked from its operands and to the Value it produced
+// the output can be rendered with e.g. `dot -Tsvg graph.dot -o graph.svg`
+func WriteDot(w io.Writer, root *Value) error {
+	visited := map[*Value]bool{}
+	nodes := []*Value{}
+	topologicalSort(root, &visited, &nodes)
+
+	// assign a stable identifier to each node so that the output does not depend
+	// on the iteration order of the children sets
+	ids := make(map[*Value]int, len(nodes))
+	for idx, n := range nodes {
+		ids[n] = idx
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph G {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+
+	for idx, n := range nodes {
+		fmt.Fprintf(bw, "\tv%d [shape=record, label=\"{ data %.4f | grad %.4f }\"];\n", idx, n.data, n.grad)
+
+		// leaves have no operation node
+		if _, ok := n.op.(None); ok {
+			continue
+		}
+		fmt.Fprintf(bw, "\tv%dop [label=\"%s\"];\n", idx, n.op.symbol())
+		fmt.Fprintf(bw, "\tv%dop -> v%d;\n", idx, idx)
+
+		children := make([]int, 0, len(n.children))
+		for child := range n.children {
+			children = append(children, ids[child])
+		}
+		sort.Ints(children)
+		for _, c := range children {
+			fmt.Fprintf(bw, "\tv%d -> v%dop;\n", c, idx)
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
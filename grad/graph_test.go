@@ -0,0 +1,46 @@
+package grad
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDot(t *testing.T) {
+	// (a*b + c)^2
+	a := NewValue(2.0)
+	b := NewValue(-3.0)
+	c := NewValue(10.0)
+	d := a.Mul(b).Add(c).Pow(2)
+	d.BackwardPass()
+
+	var buf bytes.Buffer
+	if err := WriteDot(&buf, d); err != nil {
+		t.Fatalf("Writing the DOT document failed with error: %v", err)
+	}
+	dot := buf.String()
+
+	if !strings.HasPrefix(dot, "digraph G {") || !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("The DOT document is not a well formed digraph, got:\n%s", dot)
+	}
+
+	// 6 values (a, b, c, a*b, a*b+c, (a*b+c)^2) and 3 operations
+	if got := strings.Count(dot, "shape=record"); got != 6 {
+		t.Errorf("The DOT document has the wrong number of value nodes, got:%d, want:%d", got, 6)
+	}
+	for _, symbol := range []string{"*", "+", "^"} {
+		if !strings.Contains(dot, "[label=\""+symbol+"\"]") {
+			t.Errorf("The DOT document does not contain the %q operation node", symbol)
+		}
+	}
+
+	// 3 op -> value edges plus 5 operand -> op edges
+	if got := strings.Count(dot, "->"); got != 8 {
+		t.Errorf("The DOT document has the wrong number of edges, got:%d, want:%d", got, 8)
+	}
+
+	// root gradient is always one
+	if !strings.Contains(dot, "{ data 16.0000 | grad 1.0000 }") {
+		t.Errorf("The DOT document does not show the root data and grad, got:\n%s", dot)
+	}
+}
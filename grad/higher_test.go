@@ -0,0 +1,143 @@
+package grad
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSecondDerivatives(t *testing.T) {
+	// each op is differentiated twice, the first derivative must match the one of BackwardPass
+	// and the second one the finite difference of the first
+	secondTestTable := []struct {
+		name string
+		f    func(*Value) *Value
+		x    float64
+	}{
+		{"Mul", func(x *Value) *Value { return x.Mul(x).Mul(x) }, 1.3},
+		{"Div", func(x *Value) *Value { return NewValue(2.0).Div(x) }, 0.7},
+		{"Pow", func(x *Value) *Value { return x.Pow(2.5) }, 1.9},
+		{"Sub", func(x *Value) *Value { return x.Pow(3).Sub(x.Mul(x)) }, -0.4},
+		{"Relu", func(x *Value) *Value { return x.Mul(x).Relu() }, 0.8},
+		{"Tanh", (*Value).Tanh, 0.6},
+		{"Sigmoid", (*Value).Sigmoid, -1.1},
+		{"LeakyRelu", func(x *Value) *Value { return x.Pow(3).LeakyRelu(0.01) }, -0.9},
+		{"Elu", func(x *Value) *Value { return x.Elu(1.0) }, -0.5},
+		{"Gelu", (*Value).Gelu, 0.3},
+		{"Gelu", (*Value).Gelu, -1.7},
+		{"Softplus", (*Value).Softplus, 0.2},
+		{"Identity", func(x *Value) *Value { return x.Mul(x).Identity() }, 2.0},
+		{"Exp", (*Value).Exp, 0.5},
+		{"Log", (*Value).Log, 1.5},
+		{"Sqrt", (*Value).Sqrt, 3.0},
+		{"Abs", func(x *Value) *Value { return x.Pow(3).Abs() }, -1.2},
+		{"Sin", (*Value).Sin, 0.9},
+		{"Cos", (*Value).Cos, 0.9},
+		{"Max", func(x *Value) *Value { return x.Mul(x).Max(x) }, 1.4},
+		{"Min", func(x *Value) *Value { return x.Mul(x).Min(NewValue(10.0)) }, 1.4},
+		{"Clamp", func(x *Value) *Value { return x.Exp().Clamp(-5, 5) }, 0.4},
+	}
+
+	// first derivative computed with the float gradients
+	first := func(f func(*Value) *Value, x float64) float64 {
+		v := NewValue(x)
+		f(v).BackwardPass()
+		return v.GetGrad()
+	}
+
+	for _, tc := range secondTestTable {
+		x := NewValue(tc.x)
+		tc.f(x).BackwardPassGraph()
+		g := x.GradValue()
+
+		if math.Abs(g.GetData()-first(tc.f, tc.x)) > 1e-9 {
+			t.Errorf("First derivative of %s at %f was incorrect, got:%f, want:%f", tc.name, tc.x, g.GetData(), first(tc.f, tc.x))
+		}
+
+		g.BackwardPass()
+		want := finiteDiff(func(x float64) float64 { return first(tc.f, x) }, tc.x)
+		if math.Abs(x.GetGrad()-want) > 1e-5 {
+			t.Errorf("Second derivative of %s at %f was incorrect, got:%f, want:%f", tc.name, tc.x, x.GetGrad(), want)
+		}
+	}
+}
+
+func TestGradValueUnused(t *testing.T) {
+	x, y := NewValue(1.0), NewValue(2.0)
+	x.Mul(x).BackwardPassGraph()
+
+	if y.GradValue().GetData() != 0.0 {
+		t.Errorf("The gradient of an unused value should be 0, got:%f", y.GradValue().GetData())
+	}
+	if x.GetGrad() != 0.0 {
+		t.Errorf("Graph mode should not change the float gradients, got:%f", x.GetGrad())
+	}
+}
+
+func TestHessianVectorProduct(t *testing.T) {
+	// f = x0^2 * x1 + sin(x1) * x2
+	fn := func(x []*Value) *Value {
+		return x[0].Pow(2).Mul(x[1]).Add(x[1].Sin().Mul(x[2]))
+	}
+	x := []float64{0.5, 1.2, -0.8}
+	vec := []float64{1.0, -2.0, 0.5}
+
+	// H = [[2x1, 2x0, 0], [2x0, -sin(x1)x2, cos(x1)], [0, cos(x1), 0]]
+	h := [][]float64{
+		{2 * x[1], 2 * x[0], 0},
+		{2 * x[0], -math.Sin(x[1]) * x[2], math.Cos(x[1])},
+		{0, math.Cos(x[1]), 0},
+	}
+
+	hv, err := HessianVectorProduct(fn, x, vec)
+	if err != nil {
+		t.Fatalf("Hessian vector product failed with error: %v", err)
+	}
+	for i := range h {
+		want := 0.0
+		for j := range vec {
+			want += h[i][j] * vec[j]
+		}
+		if math.Abs(hv[i]-want) > 1e-12 {
+			t.Errorf("Hessian vector product at index %d was incorrect, got:%f, want:%f", i, hv[i], want)
+		}
+	}
+
+	if _, err := HessianVectorProduct(fn, x, vec[:2]); err == nil {
+		t.Errorf("Hessian vector product with a vector of the wrong length should have failed")
+	}
+}
+
+func TestGradientPenalty(t *testing.T) {
+	// the squared norm of the gradient of a model output with respect to its inputs
+	// can be minimized like any other loss, its gradient reaching the weights
+	m := NewModel(2, []int{3, 1}, WithActivations(ActTanh, ActIdentity), WithSeed(4))
+	inputs := []*Value{NewValue(0.3), NewValue(-0.6)}
+
+	penalty := func() *Value {
+		out := inputs
+		for _, l := range m.layers {
+			out = l.feedForward(out)
+		}
+		out[0].BackwardPassGraph()
+		p := NewValue(0.0)
+		for _, in := range inputs {
+			p = p.Add(in.GradValue().Pow(2))
+		}
+		return p
+	}
+
+	m.ZeroGrad()
+	penalty().BackwardPass()
+	for i, p := range m.Params() {
+		orig := p.data
+		p.data = orig + 1e-6
+		plus := penalty().GetData()
+		p.data = orig - 1e-6
+		minus := penalty().GetData()
+		p.data = orig
+
+		if want := (plus - minus) / 2e-6; math.Abs(p.GetGrad()-want) > 1e-5 {
+			t.Errorf("Gradient of the penalty for parameter %d was incorrect, got:%f, want:%f", i, p.GetGrad(), want)
+		}
+	}
+}
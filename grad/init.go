@@ -0,0 +1,132 @@
+package grad
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Initializer returns the starting weights of a layer made of fanOut neurons
+// each one receiving fanIn inputs, i.e. fanOut rows of fanIn weights
+// any function with this signature can be used as a custom initializer
+// biases are not affected and always start at 0
+type Initializer func(rng *rand.Rand, fanIn int, fanOut int) [][]float64
+
+// UniformInit draws every weight uniformly from [min, max)
+// UniformInit(-1, 1) is the default used by NewLayer and NewNeuron
+func UniformInit(min, max float64) Initializer {
+	return func(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+		return fill(fanIn, fanOut, func() float64 {
+			return min + rng.Float64()*(max-min)
+		})
+	}
+}
+
+// ConstantInit sets every weight to c
+func ConstantInit(c float64) Initializer {
+	return func(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+		return fill(fanIn, fanOut, func() float64 {
+			return c
+		})
+	}
+}
+
+// Xavier/Glorot uniform initialization, suited to tanh and sigmoid layers
+func XavierUniform(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+	limit := math.Sqrt(6.0 / float64(fanIn+fanOut))
+
+	return UniformInit(-limit, limit)(rng, fanIn, fanOut)
+}
+
+// Xavier/Glorot normal initialization, suited to tanh and sigmoid layers
+func XavierNormal(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+	return normalInit(rng, fanIn, fanOut, math.Sqrt(2.0/float64(fanIn+fanOut)))
+}
+
+// He/Kaiming uniform initialization, suited to ReLU layers
+func HeUniform(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+	limit := math.Sqrt(6.0 / float64(fanIn))
+
+	return UniformInit(-limit, limit)(rng, fanIn, fanOut)
+}
+
+// He/Kaiming normal initialization, suited to ReLU layers
+func HeNormal(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+	return normalInit(rng, fanIn, fanOut, math.Sqrt(2.0/float64(fanIn)))
+}
+
+// LeCun uniform initialization
+func LeCunUniform(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+	limit := math.Sqrt(3.0 / float64(fanIn))
+
+	return UniformInit(-limit, limit)(rng, fanIn, fanOut)
+}
+
+// LeCun normal initialization, suited to ELU and similar layers
+func LeCunNormal(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+	return normalInit(rng, fanIn, fanOut, math.Sqrt(1.0/float64(fanIn)))
+}
+
+// Orthogonal draws a random normal matrix and orthonormalizes it with Gram-Schmidt
+// the rows are orthonormal when fanOut <= fanIn, otherwise the columns are
+func Orthogonal(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+	if fanOut <= fanIn {
+		return gramSchmidt(normalInit(rng, fanIn, fanOut, 1.0))
+	}
+
+	// orthonormalize the columns working on the transposed matrix
+	t := gramSchmidt(normalInit(rng, fanOut, fanIn, 1.0))
+	w := fill(fanIn, fanOut, func() float64 { return 0.0 })
+	for i := range t {
+		for j := range t[i] {
+			w[j][i] = t[i][j]
+		}
+	}
+
+	return w
+}
+
+// weights drawn from a normal distribution with mean 0 and standard deviation std
+func normalInit(rng *rand.Rand, fanIn int, fanOut int, std float64) [][]float64 {
+	return fill(fanIn, fanOut, func() float64 {
+		return rng.NormFloat64() * std
+	})
+}
+
+// fanOut rows of fanIn values each one returned by next
+func fill(fanIn int, fanOut int, next func() float64) [][]float64 {
+	w := make([][]float64, fanOut)
+	for i := range w {
+		w[i] = make([]float64, fanIn)
+		for j := range w[i] {
+			w[i][j] = next()
+		}
+	}
+
+	return w
+}
+
+// makes the rows of m orthonormal (in place), m must not have more rows than columns
+func gramSchmidt(m [][]float64) [][]float64 {
+	for i := range m {
+		for j := 0; j < i; j++ {
+			dot := 0.0
+			for k := range m[i] {
+				dot += m[i][k] * m[j][k]
+			}
+			for k := range m[i] {
+				m[i][k] -= dot * m[j][k]
+			}
+		}
+
+		norm := 0.0
+		for _, x := range m[i] {
+			norm += x * x
+		}
+		norm = math.Sqrt(norm)
+		for k := range m[i] {
+			m[i][k] /= norm
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,137 @@
+package grad
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestInitializersShape(t *testing.T) {
+	initTestTable := []struct {
+		name string
+		init Initializer
+	}{
+		{"UniformInit", UniformInit(-0.5, 0.5)},
+		{"ConstantInit", ConstantInit(0.1)},
+		{"XavierUniform", XavierUniform},
+		{"XavierNormal", XavierNormal},
+		{"HeUniform", HeUniform},
+		{"HeNormal", HeNormal},
+		{"LeCunUniform", LeCunUniform},
+		{"LeCunNormal", LeCunNormal},
+		{"Orthogonal", Orthogonal},
+	}
+
+	for _, table := range initTestTable {
+		for _, shape := range [][2]int{{3, 5}, {5, 3}, {4, 4}} {
+			a := table.init(rand.New(rand.NewSource(1)), shape[0], shape[1])
+			b := table.init(rand.New(rand.NewSource(1)), shape[0], shape[1])
+
+			if len(a) != shape[1] {
+				t.Errorf("%s produced %d rows, want: %d.", table.name, len(a), shape[1])
+				continue
+			}
+			for i := range a {
+				if len(a[i]) != shape[0] {
+					t.Errorf("%s produced a row of %d weights, want: %d.", table.name, len(a[i]), shape[0])
+					continue
+				}
+				for j := range a[i] {
+					if a[i][j] != b[i][j] {
+						t.Errorf("%s is not reproducible with the same seed at (%d, %d).", table.name, i, j)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestInitializersScale(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// uniform initializers must stay within their limits
+	limit := math.Sqrt(6.0 / float64(30+20))
+	for _, row := range XavierUniform(rng, 30, 20) {
+		for _, w := range row {
+			if math.Abs(w) > limit {
+				t.Errorf("XavierUniform weight out of bounds, got: %f, limit: %f.", w, limit)
+			}
+		}
+	}
+
+	// the variance of He normal weights is close to 2/fanIn
+	sum := 0.0
+	ws := HeNormal(rng, 50, 400)
+	for _, row := range ws {
+		for _, w := range row {
+			sum += w * w
+		}
+	}
+	variance := sum / float64(50*400)
+	if math.Abs(variance-2.0/50.0) > 0.004 {
+		t.Errorf("HeNormal variance was incorrect, got: %f, want: %f.", variance, 2.0/50.0)
+	}
+
+	for _, row := range ConstantInit(0.25)(rng, 3, 2) {
+		for _, w := range row {
+			if w != 0.25 {
+				t.Errorf("ConstantInit weight was incorrect, got: %f, want: %f.", w, 0.25)
+			}
+		}
+	}
+}
+
+func TestOrthogonal(t *testing.T) {
+	// rows are orthonormal when fanOut <= fanIn, columns otherwise
+	for _, shape := range [][2]int{{6, 4}, {4, 6}} {
+		w := Orthogonal(rand.New(rand.NewSource(1)), shape[0], shape[1])
+		vectors := w
+		if shape[1] > shape[0] {
+			vectors = make([][]float64, shape[0])
+			for j := range vectors {
+				for i := range w {
+					vectors[j] = append(vectors[j], w[i][j])
+				}
+			}
+		}
+
+		for i := range vectors {
+			for j := range vectors {
+				dot := 0.0
+				for k := range vectors[i] {
+					dot += vectors[i][k] * vectors[j][k]
+				}
+				want := 0.0
+				if i == j {
+					want = 1.0
+				}
+				if math.Abs(dot-want) > 1e-9 {
+					t.Errorf("Orthogonal %v vectors %d and %d have dot product %f, want: %f.", shape, i, j, dot, want)
+				}
+			}
+		}
+	}
+}
+
+func TestNewModelInitializers(t *testing.T) {
+	custom := func(rng *rand.Rand, fanIn int, fanOut int) [][]float64 {
+		return ConstantInit(float64(fanIn))(rng, fanIn, fanOut)
+	}
+	m := NewModel(3, []int{4, 1}, WithInitializers(ConstantInit(0.5), custom), WithSeed(1))
+
+	for _, p := range m.layers[0].params()[:3] {
+		if p.GetData() != 0.5 {
+			t.Errorf("First layer weight was not initialized by its initializer, got: %f, want: %f.", p.GetData(), 0.5)
+		}
+	}
+	for _, w := range m.layers[1].neurons[0].weights {
+		if w.GetData() != 4.0 {
+			t.Errorf("Second layer weight was not initialized by the custom initializer, got: %f, want: %f.", w.GetData(), 4.0)
+		}
+	}
+	for _, n := range m.layers[0].neurons {
+		if n.bias.GetData() != 0.0 {
+			t.Errorf("Bias was not initialized to zero, got: %f.", n.bias.GetData())
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package grad
+
+import (
+	"fmt"
+	"math"
+)
+
+// the following losses share MSE's shape so they can be used anywhere
+// a func(*Value, float64) *Value is expected (e.g. as NewXVal's lossFunc)
+// binary classification losses treat positive labels as the positive class
+// and any other label (e.g. -1 or 0) as the negative one
+
+// max-margin hinge loss with a margin of 1
+func Hinge(predicted *Value, expected float64) *Value {
+	return NewHingeLoss(1.0)(predicted, expected)
+}
+
+// NewHingeLoss returns the hinge loss max(0, margin - y*predicted) with y in {-1, +1}
+func NewHingeLoss(margin float64) func(*Value, float64) *Value {
+	return func(predicted *Value, expected float64) *Value {
+		return NewValue(margin).Sub(predicted.Mul(NewValue(sign(expected)))).Relu()
+	}
+}
+
+// logistic loss on logits, equivalent to the binary cross entropy of sigmoid(predicted)
+func BinaryCrossEntropy(predicted *Value, expected float64) *Value {
+	// log(1 + e^(-y*z)) with y in {-1, +1}
+	return predicted.Mul(NewValue(-sign(expected))).Softplus()
+}
+
+// mean absolute error
+func MAE(predicted *Value, expected float64) *Value {
+	return predicted.Sub(NewValue(expected)).Abs()
+}
+
+// Huber loss with a delta of 1
+func Huber(predicted *Value, expected float64) *Value {
+	return NewHuberLoss(1.0)(predicted, expected)
+}
+
+// NewHuberLoss returns a loss that is quadratic for errors smaller than delta and linear otherwise
+func NewHuberLoss(delta float64) func(*Value, float64) *Value {
+	return func(predicted *Value, expected float64) *Value {
+		diff := predicted.Sub(NewValue(expected))
+		if math.Abs(diff.GetData()) <= delta {
+			return diff.Pow(2).Mul(NewValue(0.5))
+		}
+
+		return diff.Abs().Sub(NewValue(0.5 * delta)).Mul(NewValue(delta))
+	}
+}
+
+// logarithm of the hyperbolic cosine of the error
+func LogCosh(predicted *Value, expected float64) *Value {
+	// log(cosh(x)) = |x| + log(1 + e^(-2|x|)) - log(2) does not overflow for large errors
+	abs := predicted.Sub(NewValue(expected)).Abs()
+	tail := abs.Mul(NewValue(-2.0)).Exp().Add(NewValue(1.0)).Log()
+
+	return abs.Add(tail).Sub(NewValue(math.Ln2))
+}
+
+// multi class cross entropy between softmax(logits) and the target distribution
+// (usually a one hot vector, see OneHot)
+func SoftmaxCrossEntropy(logits []*Value, expected []float64) *Value {
+	if len(logits) != len(expected) {
+		panic(fmt.Sprintf("gograd: got %d logits for %d targets", len(logits), len(expected)))
+	}
+
+	// shifting by the largest logit does not change the result but avoids overflows
+	shift := math.Inf(-1)
+	for _, l := range logits {
+		shift = math.Max(shift, l.GetData())
+	}
+	sum := NewValue(0.0)
+	for _, l := range logits {
+		sum = sum.Add(l.Sub(NewValue(shift)).Exp())
+	}
+	logSumExp := sum.Log().Add(NewValue(shift))
+
+	// sum of -t_i * log(softmax_i) where log(softmax_i) = z_i - logSumExp
+	loss := NewValue(0.0)
+	for idx, l := range logits {
+		if expected[idx] == 0 {
+			continue
+		}
+		loss = loss.Add(logSumExp.Sub(l).Mul(NewValue(expected[idx])))
+	}
+
+	return loss
+}
+
+// OneHot returns a vector of n zeros with a one at index class
+func OneHot(class int, n int) []float64 {
+	v := make([]float64, n)
+	v[class] = 1.0
+
+	return v
+}
+
+// VectorLoss turns a loss for a single output into one averaged over
+// every output of a model, e.g. VectorLoss(MSE)
+func VectorLoss(f func(*Value, float64) *Value) func([]*Value, []float64) *Value {
+	return func(predicted []*Value, expected []float64) *Value {
+		if len(predicted) != len(expected) {
+			panic(fmt.Sprintf("gograd: got %d predictions for %d targets", len(predicted), len(expected)))
+		}
+
+		losses := map2Losses(predicted, expected, f)
+		sum := NewValue(0.0)
+		for _, l := range losses {
+			sum = sum.Add(l)
+		}
+
+		return sum.Div(len(losses))
+	}
+}
+
+// labels greater than zero belong to the positive class
+func sign(label float64) float64 {
+	if label > 0 {
+		return 1.0
+	}
+
+	return -1.0
+}
@@ -0,0 +1,84 @@
+package grad
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLosses(t *testing.T) {
+	// data table for test, each gradient is checked against the finite difference approximation
+	lossTestTable := []struct {
+		name string
+		f    func(*Value, float64) *Value
+		p    float64
+		y    float64
+		n    float64
+	}{
+		{"Hinge", Hinge, 0.3, 1.0, 0.7},
+		{"Hinge", Hinge, 0.3, -1.0, 1.3},
+		{"Hinge", Hinge, 2.5, 1.0, 0.0},
+		{"Hinge", NewHingeLoss(2.0), 0.3, 1.0, 1.7},
+		{"BinaryCrossEntropy", BinaryCrossEntropy, 0.3, 1.0, 0.554355244},
+		{"BinaryCrossEntropy", BinaryCrossEntropy, 0.3, -1.0, 0.854355244},
+		{"BinaryCrossEntropy", BinaryCrossEntropy, 0.3, 0.0, 0.854355244},
+		{"BinaryCrossEntropy", BinaryCrossEntropy, -1.2, -1.0, 0.263282467},
+		{"MAE", MAE, 0.3, 1.0, 0.7},
+		{"MAE", MAE, 3.0, -1.0, 4.0},
+		{"Huber", Huber, 0.3, 1.0, 0.245},
+		{"Huber", Huber, 3.0, -1.0, 3.5},
+		{"LogCosh", LogCosh, 0.3, 1.0, 0.227270229},
+		{"LogCosh", LogCosh, 3.0, -1.0, 3.307188226},
+		{"MSE", MSE, 0.3, 1.0, 0.49},
+	}
+
+	for _, table := range lossTestTable {
+		p := NewValue(table.p)
+		out := table.f(p, table.y)
+		out.BackwardPass()
+
+		if math.Abs(out.GetData()-table.n) > 1e-9 {
+			t.Errorf("%s loss was incorrect for p=%f, y=%f, got: %0.9f, want: %0.9f.", table.name, table.p, table.y, out.GetData(), table.n)
+		}
+
+		ref := func(x float64) float64 { return table.f(NewValue(x), table.y).GetData() }
+		want := finiteDiff(ref, table.p)
+		if math.Abs(p.GetGrad()-want) > 1e-6 {
+			t.Errorf("%s loss derivative was incorrect for p=%f, y=%f, got: %0.9f, want: %0.9f.", table.name, table.p, table.y, p.GetGrad(), want)
+		}
+	}
+}
+
+func TestSoftmaxCrossEntropy(t *testing.T) {
+	logits := []*Value{NewValue(1.0), NewValue(2.0), NewValue(0.5)}
+	loss := SoftmaxCrossEntropy(logits, OneHot(1, 3))
+	loss.BackwardPass()
+
+	if math.Abs(loss.GetData()-0.464368784) > 1e-9 {
+		t.Errorf("Softmax cross entropy was incorrect, got: %0.9f, want: %0.9f.", loss.GetData(), 0.464368784)
+	}
+
+	// the gradient w.r.t. each logit is softmax_i - t_i
+	sum := math.Exp(1.0) + math.Exp(2.0) + math.Exp(0.5)
+	want := []float64{math.Exp(1.0) / sum, math.Exp(2.0)/sum - 1, math.Exp(0.5) / sum}
+	for idx, l := range logits {
+		if math.Abs(l.GetGrad()-want[idx]) > 1e-9 {
+			t.Errorf("Softmax cross entropy derivative at index %d was incorrect, got: %0.9f, want: %0.9f.", idx, l.GetGrad(), want[idx])
+		}
+	}
+
+	// very large logits must not overflow
+	big := SoftmaxCrossEntropy([]*Value{NewValue(1000.0), NewValue(0.0)}, OneHot(0, 2))
+	if math.IsNaN(big.GetData()) || math.IsInf(big.GetData(), 0) {
+		t.Errorf("Softmax cross entropy overflowed with large logits, got: %f.", big.GetData())
+	}
+}
+
+func TestVectorLoss(t *testing.T) {
+	preds := []*Value{NewValue(1.0), NewValue(3.0)}
+	loss := VectorLoss(MSE)(preds, []float64{0.0, 1.0})
+
+	// (1 + 4) / 2
+	if math.Abs(loss.GetData()-2.5) > 1e-9 {
+		t.Errorf("Vector MSE loss was incorrect, got: %f, want: %f.", loss.GetData(), 2.5)
+	}
+}
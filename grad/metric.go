@@ -0,0 +1,129 @@
+package grad
+
+import (
+	"math"
+	"sort"
+)
+
+// Metric scores the raw outputs of a model against the expected labels
+// binary classification metrics expect labels of ±1 and treat positive outputs as the positive class
+type Metric struct {
+	Name     string
+	Maximize bool // whether a higher score is better
+	Score    func(preds []float64, labels []float64) float64
+}
+
+// better tells whether score a beats score b (ties are not better)
+func (m Metric) better(a float64, b float64) bool {
+	if m.Maximize {
+		return a > b
+	}
+
+	return a < b
+}
+
+var (
+	// MetricAccuracy is the fraction of outputs with the same sign of their label
+	MetricAccuracy = Metric{Name: "accuracy", Maximize: true, Score: accuracyScore}
+	// MetricMSE is the mean squared error, for regression
+	MetricMSE = Metric{Name: "mse", Maximize: false, Score: mseScore}
+	// MetricF1 is the harmonic mean of precision and recall of the positive class
+	MetricF1 = Metric{Name: "f1", Maximize: true, Score: f1Score}
+	// MetricAUC is the area under the ROC curve, i.e. the probability that a random
+	// positive sample gets a higher output than a random negative one
+	MetricAUC = Metric{Name: "auc", Maximize: true, Score: aucScore}
+	// MetricLogLoss is the binary cross entropy of the outputs taken as logits
+	MetricLogLoss = Metric{Name: "log_loss", Maximize: false, Score: logLossScore}
+)
+
+func accuracyScore(preds []float64, labels []float64) float64 {
+	hits := 0.0
+	for idx := range preds {
+		if (preds[idx] > 0.0) == (labels[idx] > 0.0) {
+			hits += 1.0
+		}
+	}
+
+	return hits / float64(len(preds))
+}
+
+func mseScore(preds []float64, labels []float64) float64 {
+	sum := 0.0
+	for idx := range preds {
+		sum += (preds[idx] - labels[idx]) * (preds[idx] - labels[idx])
+	}
+
+	return sum / float64(len(preds))
+}
+
+// 0 when there are no true positives
+func f1Score(preds []float64, labels []float64) float64 {
+	tp, fp, fn := 0.0, 0.0, 0.0
+	for idx := range preds {
+		switch {
+		case preds[idx] > 0.0 && labels[idx] > 0.0:
+			tp++
+		case preds[idx] > 0.0:
+			fp++
+		case labels[idx] > 0.0:
+			fn++
+		}
+	}
+	if tp == 0 {
+		return 0.0
+	}
+
+	return 2 * tp / (2*tp + fp + fn)
+}
+
+// computed from the ranks of the outputs (Mann-Whitney U), tied outputs share their average rank
+// 0.5 when there are samples of a single class only
+func aucScore(preds []float64, labels []float64) float64 {
+	order := indices(len(preds))
+	sort.SliceStable(order, func(i, j int) bool { return preds[order[i]] < preds[order[j]] })
+
+	ranks := make([]float64, len(preds))
+	for i := 0; i < len(order); {
+		j := i
+		for j < len(order) && preds[order[j]] == preds[order[i]] {
+			j++
+		}
+		// ranks start from 1, so the average of i+1...j
+		for k := i; k < j; k++ {
+			ranks[order[k]] = float64(i+j+1) / 2.0
+		}
+		i = j
+	}
+
+	pos, neg, sum := 0.0, 0.0, 0.0
+	for idx, l := range labels {
+		if l > 0.0 {
+			pos++
+			sum += ranks[idx]
+		} else {
+			neg++
+		}
+	}
+	if pos == 0 || neg == 0 {
+		return 0.5
+	}
+
+	return (sum - pos*(pos+1)/2) / (pos * neg)
+}
+
+// probabilities are clipped to avoid infinite losses
+func logLossScore(preds []float64, labels []float64) float64 {
+	const eps = 1e-15
+
+	sum := 0.0
+	for idx := range preds {
+		p := math.Min(math.Max(sigmoid(preds[idx]), eps), 1-eps)
+		if labels[idx] > 0.0 {
+			sum -= math.Log(p)
+		} else {
+			sum -= math.Log(1 - p)
+		}
+	}
+
+	return sum / float64(len(preds))
+}
@@ -0,0 +1,53 @@
+package grad
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	preds := []float64{2.0, -1.0, 0.5, -0.5, 1.0, -2.0}
+	labels := []float64{1.0, -1.0, -1.0, 1.0, 1.0, -1.0}
+
+	for _, tc := range []struct {
+		metric Metric
+		want   float64
+	}{
+		// 4 right signs out of 6
+		{MetricAccuracy, 4.0 / 6.0},
+		{MetricMSE, (1.0 + 0.0 + 2.25 + 2.25 + 0.0 + 1.0) / 6.0},
+		// 2 true positives, 1 false positive and 1 false negative
+		{MetricF1, 4.0 / 6.0},
+		// 8 of the 9 positive/negative pairs are ranked correctly
+		{MetricAUC, 8.0 / 9.0},
+		{MetricLogLoss, (math.Log1p(math.Exp(-2.0)) + math.Log1p(math.Exp(-1.0)) + math.Log1p(math.Exp(0.5)) +
+			math.Log1p(math.Exp(0.5)) + math.Log1p(math.Exp(-1.0)) + math.Log1p(math.Exp(-2.0))) / 6.0},
+	} {
+		if got := tc.metric.Score(preds, labels); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("Metric %s was incorrect, got:%f, want:%f", tc.metric.Name, got, tc.want)
+		}
+	}
+}
+
+func TestMetricEdgeCases(t *testing.T) {
+	// tied outputs count as half a correct ranking
+	if got := MetricAUC.Score([]float64{1.0, 1.0}, []float64{1.0, -1.0}); got != 0.5 {
+		t.Errorf("AUC with tied outputs was incorrect, got:%f, want:%f", got, 0.5)
+	}
+	if got := MetricAUC.Score([]float64{1.0, 2.0}, []float64{1.0, 1.0}); got != 0.5 {
+		t.Errorf("AUC with a single class was incorrect, got:%f, want:%f", got, 0.5)
+	}
+	if got := MetricF1.Score([]float64{-1.0, -1.0}, []float64{1.0, -1.0}); got != 0.0 {
+		t.Errorf("F1 without true positives was incorrect, got:%f, want:%f", got, 0.0)
+	}
+	if got := MetricLogLoss.Score([]float64{1000.0}, []float64{-1.0}); math.IsInf(got, 0) {
+		t.Errorf("Log loss of a confident wrong output should be finite, got:%f", got)
+	}
+
+	if !MetricAccuracy.better(0.9, 0.8) || MetricAccuracy.better(0.8, 0.8) {
+		t.Errorf("Higher accuracy should be better")
+	}
+	if !MetricMSE.better(0.1, 0.2) || MetricMSE.better(0.2, 0.1) {
+		t.Errorf("Lower mean squared error should be better")
+	}
+}
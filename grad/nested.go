@@ -0,0 +1,123 @@
+package grad
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// OuterFold is the outcome of a single outer fold of nested cross validation
+type OuterFold struct {
+	Hyperpar   float64  `json:"hyperpar"`    // chosen by the inner search on the training samples
+	InnerScore float64  `json:"inner_score"` // mean inner score of the chosen hyperparameter (optimistically biased)
+	Score      float64  `json:"score"`       // metric on the outer holdout samples, never seen by the inner search
+	Inner      CVResult `json:"inner"`
+}
+
+// NestedResult is the report of nested cross validation: the mean of the outer scores
+// estimates how well the whole procedure (choosing lambda and then training) generalizes
+type NestedResult struct {
+	Folds    []OuterFold   `json:"folds"`
+	Mean     float64       `json:"mean"`
+	StdDev   float64       `json:"std_dev"`
+	CILow    float64       `json:"ci_low"`  // bounds of the 95% confidence interval of the mean
+	CIHigh   float64       `json:"ci_high"` // (t distribution, 0 wide with a single fold)
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// WriteJSON writes the whole report to w as a json document
+func (r NestedResult) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// NestedXVal evaluates the hyperparameter search itself: for each split generated by outer
+// an inner cross validation (with innerK folds and the given options) picks lambda among
+// the training samples only, then a new model is trained on all of them with that lambda
+// and scored on the holdout samples (it panics when fr is empty, as there is no lambda to pick)
+func NestedXVal(
+	data [][]float64,
+	labels []float64,
+	arch []int,
+	fr *floatingRange,
+	alpha Scheduler,
+	lossFunc func(*Value, float64) *Value,
+	outer Splitter,
+	innerK int,
+	opts ...XValOption,
+) NestedResult {
+	if len(data) != len(labels) {
+		panic(fmt.Sprintf("gograd: %d samples but %d labels", len(data), len(labels)))
+	}
+
+	var res NestedResult
+	start := time.Now()
+
+	splits := outer.Splits(labels)
+	scores := make([]float64, len(splits))
+	for idx, split := range splits {
+		trainingValues, trainingLabels := subset(data, labels, split.Train)
+		holdoutValues, holdoutLabels := subset(data, labels, split.Holdout)
+
+		// every inner search starts from the beginning of the range
+		xv := NewXVal(trainingValues, trainingLabels, arch, fr.clone(), alpha, lossFunc, innerK, opts...)
+		xv.logf("==> Outer fold %d of %d\n", idx+1, len(splits))
+		inner, err := xv.SearchBestHyperparContext(context.Background())
+		if err != nil {
+			panic(fmt.Sprintf("gograd: nested cross validation failed: %v", err))
+		}
+
+		// final training on the whole outer training set, divided in innerK groups as in the inner folds
+		fold := xv.newFold(xv.nextSeed())
+		groupedValues, groupedLabels := group(trainingValues, trainingLabels, innerK)
+		xv.miniTrain(context.Background(), fold, groupedValues, groupedLabels, NewValue(inner.Best.Hyperpar))
+		scores[idx] = xv.holdout(fold.model, holdoutValues, holdoutLabels)
+
+		xv.logf("outer fold %d: hyperpar=%.4f, inner %s=%.4f, outer %s=%.4f\n",
+			idx+1,
+			inner.Best.Hyperpar,
+			xv.metric.Name,
+			inner.Best.Mean,
+			xv.metric.Name,
+			scores[idx],
+		)
+		res.Folds = append(res.Folds, OuterFold{
+			Hyperpar:   inner.Best.Hyperpar,
+			InnerScore: inner.Best.Mean,
+			Score:      scores[idx],
+			Inner:      inner,
+		})
+	}
+
+	res.Mean = avgValue(scores)
+	res.StdDev = stdDev(scores)
+	res.CILow, res.CIHigh = res.Mean, res.Mean
+	if n := len(scores); n > 1 {
+		margin := tCritical95(n-1) * res.StdDev / math.Sqrt(float64(n))
+		res.CILow, res.CIHigh = res.Mean-margin, res.Mean+margin
+	}
+	res.Duration = time.Since(start)
+
+	return res
+}
+
+// two-sided 95% critical values of the t distribution for 1 to 30 degrees of freedom
+var tTable95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// past 30 degrees of freedom the normal approximation is close enough
+func tCritical95(df int) float64 {
+	if df <= len(tTable95) {
+		return tTable95[df-1]
+	}
+
+	return 1.96
+}
@@ -0,0 +1,84 @@
+package grad
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestNestedXVal(t *testing.T) {
+	fr := NewFloatingRange(0.0, 0.001, 0.0005)
+	res := NestedXVal(
+		GetInputs()[:60],
+		GetLabels()[:60],
+		[]int{2, 4, 1},
+		fr,
+		SchedulerFunc(Alpha),
+		MSE,
+		NewShuffledKFold(3, 1),
+		3,
+		WithXValSeed(1),
+	)
+
+	if len(res.Folds) != 3 {
+		t.Fatalf("Nested cross validation has the wrong number of outer folds, got:%d, want:%d", len(res.Folds), 3)
+	}
+
+	scores := make([]float64, len(res.Folds))
+	for idx, f := range res.Folds {
+		// each inner search must try the whole range
+		if len(f.Inner.Scores) != 3 {
+			t.Errorf("Inner search of outer fold %d tried the wrong number of values, got:%d, want:%d", idx, len(f.Inner.Scores), 3)
+		}
+		if f.Hyperpar != f.Inner.Best.Hyperpar || f.InnerScore != f.Inner.Best.Mean {
+			t.Errorf("Outer fold %d does not report the best inner hyperparameter, got:%f, want:%f", idx, f.Hyperpar, f.Inner.Best.Hyperpar)
+		}
+		if f.Score < 0.0 || f.Score > 1.0 {
+			t.Errorf("Outer fold %d has an invalid score, got:%f", idx, f.Score)
+		}
+		scores[idx] = f.Score
+	}
+
+	if math.Abs(res.Mean-avgValue(scores)) > 1e-12 {
+		t.Errorf("Nested mean was incorrect, got:%f, want:%f", res.Mean, avgValue(scores))
+	}
+	// 3 folds: 2 degrees of freedom
+	margin := 4.303 * stdDev(scores) / math.Sqrt(3)
+	if math.Abs(res.CILow-(res.Mean-margin)) > 1e-12 || math.Abs(res.CIHigh-(res.Mean+margin)) > 1e-12 {
+		t.Errorf("Confidence interval was incorrect, got:[%f, %f], want:[%f, %f]", res.CILow, res.CIHigh, res.Mean-margin, res.Mean+margin)
+	}
+
+	// the range passed in is left untouched
+	if fr.current != fr.start {
+		t.Errorf("Nested cross validation consumed the hyperparameter range")
+	}
+
+	var buf bytes.Buffer
+	if err := res.WriteJSON(&buf); err != nil {
+		t.Fatalf("Writing the json report failed with error: %v", err)
+	}
+	var decoded NestedResult
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("The json report could not be decoded: %v", err)
+	}
+	if len(decoded.Folds) != len(res.Folds) || decoded.Mean != res.Mean {
+		t.Errorf("The decoded json report does not match the original one")
+	}
+}
+
+func TestTCritical95(t *testing.T) {
+	for _, tc := range []struct {
+		df   int
+		want float64
+	}{
+		{1, 12.706},
+		{9, 2.262},
+		{30, 2.042},
+		{100, 1.96},
+	} {
+		if got := tCritical95(tc.df); got != tc.want {
+			t.Errorf("t critical value with %d degrees of freedom was incorrect, got:%f, want:%f", tc.df, got, tc.want)
+		}
+	}
+}
@@ -1,8 +1,8 @@
 package grad
 
 import (
+	"fmt"
 	"math/rand"
-	"time"
 )
 
 // base interface
@@ -10,20 +10,90 @@ type base interface {
 	params() []*Value
 }
 
+// activation function applied by every neuron of a layer
+type Activation string
+
+const (
+	ActIdentity  Activation = "identity"
+	ActRelu      Activation = "relu"
+	ActTanh      Activation = "tanh"
+	ActSigmoid   Activation = "sigmoid"
+	ActLeakyRelu Activation = "leaky_relu"
+	ActElu       Activation = "elu"
+	ActGelu      Activation = "gelu"
+	ActSoftplus  Activation = "softplus"
+)
+
+// default parameters of the activations that need one
+const (
+	leakyReluSlope = 0.01
+	eluAlpha       = 1.0
+)
+
+func (a Activation) valid() bool {
+	switch a {
+	case ActIdentity, ActRelu, ActTanh, ActSigmoid, ActLeakyRelu, ActElu, ActGelu, ActSoftplus:
+		return true
+	}
+
+	return false
+}
+
+func (a Activation) apply(v *Value) *Value {
+	switch a {
+	case ActRelu:
+		return v.Relu()
+	case ActTanh:
+		return v.Tanh()
+	case ActSigmoid:
+		return v.Sigmoid()
+	case ActLeakyRelu:
+		return v.LeakyRelu(leakyReluSlope)
+	case ActElu:
+		return v.Elu(eluAlpha)
+	case ActGelu:
+		return v.Gelu()
+	case ActSoftplus:
+		return v.Softplus()
+	}
+
+	// identity does not need an extra node in the graph
+	return v
+}
+
 // feed forward neural network neuron implementation
 type Neuron struct {
 	weights []*Value
 	bias    *Value
-	nonlin  bool
+	act     Activation
 }
 
-func NewNeuron(inputNum int, nonlin bool) *Neuron {
+// weights are drawn from rng
+func NewNeuron(inputNum int, act Activation, rng *rand.Rand) *Neuron {
+	if !act.valid() {
+		panic(fmt.Sprintf("gograd: unknown activation %q", act))
+	}
+
 	n := Neuron{
 		weights: nil,
 		bias:    NewValue(0.0),
 	}
-	n.weights = randFloats(-1.0, 1.0, inputNum)
-	n.nonlin = nonlin
+	n.weights = randFloats(rng, -1.0, 1.0, inputNum)
+	n.act = act
+
+	return &n
+}
+
+// neuron starting from the given weights (and a zero bias)
+func newNeuronFromWeights(weights []float64, act Activation) *Neuron {
+	n := Neuron{
+		weights: make([]*Value, len(weights)),
+		bias:    NewValue(0.0),
+		act:     act,
+	}
+	for i, w := range weights {
+		n.weights[i] = NewValue(w)
+	}
 
 	return &n
 }
@@ -36,8 +106,7 @@ func (n Neuron) feedForward(inputs []*Value) *Value {
 	}
 	dot = dot.Add(n.bias)
 
-	return dot
-
+	return n.act.apply(dot)
 }
 
 func (n Neuron) params() []*Value {
@@ -62,10 +131,19 @@ type Layer struct {
 	neurons []*Neuron
 }
 
-func NewLayer(inputNum int, neuronsNum int, nonlin bool) *Layer {
+// weights are generated by init drawing from rng
+// when init is nil they are drawn uniformly from [-1, 1) like NewNeuron does
+func NewLayer(inputNum int, neuronsNum int, act Activation, init Initializer, rng *rand.Rand) *Layer {
+	if !act.valid() {
+		panic(fmt.Sprintf("gograd: unknown activation %q", act))
+	}
+	if init == nil {
+		init = UniformInit(-1.0, 1.0)
+	}
+
 	l := Layer{neurons: []*Neuron{}}
-	for i := 0; i < neuronsNum; i++ {
-		l.neurons = append(l.neurons, NewNeuron(inputNum, nonlin))
+	for _, w := range init(rng, inputNum, neuronsNum) {
+		l.neurons = append(l.neurons, newNeuronFromWeights(w, act))
 	}
 
 	return &l
@@ -104,9 +182,72 @@ type Model struct {
 	layers []*Layer
 }
 
-func NewModel(inputNum int, networkArch []int) *Model {
-	// seeding random number generator with time in nanoseconds
-	rand.Seed(time.Now().UnixNano())
+// optional settings used when creating a new model
+type modelConfig struct {
+	activations  []Activation
+	initializers []Initializer
+	rng          *rand.Rand
+}
+
+type ModelOption func(*modelConfig)
+
+// WithActivations sets the activation of each layer, one per element of the network architecture
+// when not used every hidden layer applies a ReLU while the output layer stays linear
+func WithActivations(acts ...Activation) ModelOption {
+	return func(c *modelConfig) {
+		c.activations = acts
+	}
+}
+
+// WithInitializers sets the weights initializer of each layer, one per element of the network architecture
+// when not used (or for nil elements) weights are drawn uniformly from [-1, 1)
+func WithInitializers(inits ...Initializer) ModelOption {
+	return func(c *modelConfig) {
+		c.initializers = inits
+	}
+}
+
+// WithRand sets the random number generator used to initialize the weights
+// so that the same seed always produces the same model
+// when not used a generator seeded with the current time is created
+func WithRand(rng *rand.Rand) ModelOption {
+	return func(c *modelConfig) {
+		c.rng = rng
+	}
+}
+
+// WithSeed is a shorthand for WithRand(rand.New(rand.NewSource(seed)))
+func WithSeed(seed int64) ModelOption {
+	return WithRand(rand.New(rand.NewSource(seed)))
+}
+
+func NewModel(inputNum int, networkArch []int, opts ...ModelOption) *Model {
+	cfg := modelConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.rng == nil {
+		cfg.rng = timeRand()
+	}
+	if cfg.activations == nil {
+		for l := range networkArch {
+			if l != len(networkArch)-1 {
+				cfg.activations = append(cfg.activations, ActRelu)
+			} else {
+				cfg.activations = append(cfg.activations, ActIdentity)
+			}
+		}
+	}
+	if len(cfg.activations) != len(networkArch) {
+		panic(fmt.Sprintf("gograd: got %d activations for %d layers", len(cfg.activations), len(networkArch)))
+	}
+
+	if cfg.initializers == nil {
+		cfg.initializers = make([]Initializer, len(networkArch))
+	}
+	if len(cfg.initializers) != len(networkArch) {
+		panic(fmt.Sprintf("gograd: got %d initializers for %d layers", len(cfg.initializers), len(networkArch)))
+	}
 
 	arch := []int{inputNum}
 	arch = append(arch, networkArch...)
@@ -115,13 +256,19 @@ func NewModel(inputNum int, networkArch []int) *Model {
 	// looping through networkArch but reading from arch
 	// remember that len(networkArch)=len(arch)-1
 	for l := range networkArch {
-		m.layers = append(m.layers, NewLayer(arch[l], arch[l+1], l != len(networkArch)-1))
+		m.layers = append(m.layers, NewLayer(arch[l], arch[l+1], cfg.activations[l], cfg.initializers[l], cfg.rng))
 	}
 
 	return &m
 }
 
-func (m Model) FeedForward(inputs [2]float64) []*Value {
+// FeedForward returns the model outputs for the given inputs, which must be
+// as many as the inputs of the first layer
+func (m Model) FeedForward(inputs []float64) ([]*Value, error) {
+	if want := m.inputWidth(); len(inputs) != want {
+		return nil, fmt.Errorf("gograd: model expects %d inputs, got %d", want, len(inputs))
+	}
+
 	// convert inputs to Value instances
 	var inps []*Value
 	for _, el := range inputs {
@@ -132,7 +279,16 @@ func (m Model) FeedForward(inputs [2]float64) []*Value {
 		inps = l.feedForward(inps)
 	}
 
-	return inps
+	return inps, nil
+}
+
+// number of inputs accepted by the first layer
+func (m Model) inputWidth() int {
+	if len(m.layers) == 0 || len(m.layers[0].neurons) == 0 {
+		return 0
+	}
+
+	return len(m.layers[0].neurons[0].weights)
 }
 
 func (m Model) Params() []*Value {
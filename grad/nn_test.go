@@ -1,5 +1,160 @@
 package grad
 
-// func modelArchTest(t *testing.T) {
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
 
-// }
+// builds a neuron with the given weights and bias
+func newTestNeuron(weights []float64, bias float64, act Activation) *Neuron {
+	n := Neuron{
+		weights: make([]*Value, len(weights)),
+		bias:    NewValue(bias),
+		act:     act,
+	}
+	for i, w := range weights {
+		n.weights[i] = NewValue(w)
+	}
+
+	return &n
+}
+
+func TestNeuronActivation(t *testing.T) {
+	// data table for test
+	neuronTestTable := []struct {
+		weights []float64
+		bias    float64
+		inputs  []float64
+		act     Activation
+		n       float64
+	}{
+		{[]float64{1.0, 2.0}, 0.5, []float64{-1.0, -1.0}, ActRelu, 0.0},
+		{[]float64{1.0, 2.0}, 0.5, []float64{-1.0, -1.0}, ActIdentity, -2.5},
+		{[]float64{1.0, 2.0}, 0.5, []float64{1.0, 1.0}, ActRelu, 3.5},
+		{[]float64{1.0, 2.0}, 0.5, []float64{1.0, 1.0}, ActIdentity, 3.5},
+		{[]float64{1.0, 2.0}, 0.5, []float64{-1.0, -1.0}, ActLeakyRelu, -0.025},
+		{[]float64{1.0, 2.0}, 0.5, []float64{-1.0, -1.0}, ActTanh, -0.986614298},
+		{[]float64{1.0, 2.0}, 0.5, []float64{-1.0, -1.0}, ActSigmoid, 0.075858180},
+		{[]float64{1.0, 2.0}, 0.5, []float64{-1.0, -1.0}, ActElu, -0.917915001},
+		{[]float64{1.0, 2.0}, 0.5, []float64{-1.0, -1.0}, ActGelu, -0.015524163},
+		{[]float64{1.0, 2.0}, 0.5, []float64{-1.0, -1.0}, ActSoftplus, 0.078889734},
+	}
+
+	for _, table := range neuronTestTable {
+		n := newTestNeuron(table.weights, table.bias, table.act)
+		inputs := []*Value{NewValue(table.inputs[0]), NewValue(table.inputs[1])}
+		out := n.feedForward(inputs)
+
+		if math.Round(out.GetData()*1_000_000_000)/1_000_000_000 != math.Round(table.n*1_000_000_000)/1_000_000_000 {
+			t.Errorf(
+				"Neuron output was incorrect (act=%s), got: %0.9f, want: %0.9f.",
+				table.act,
+				out.GetData(),
+				table.n,
+			)
+		}
+	}
+}
+
+func TestHiddenLayerClamped(t *testing.T) {
+	// a hidden layer whose neurons all produce a negative pre-activation
+	hidden := Layer{neurons: []*Neuron{
+		newTestNeuron([]float64{-1.0, -1.0}, -0.5, ActRelu),
+		newTestNeuron([]float64{-2.0, 0.5}, -1.0, ActRelu),
+	}}
+	output := Layer{neurons: []*Neuron{
+		newTestNeuron([]float64{3.0, -4.0}, 0.25, ActIdentity),
+	}}
+	m := Model{layers: []*Layer{&hidden, &output}}
+
+	for _, h := range hidden.feedForward([]*Value{NewValue(1.0), NewValue(1.0)}) {
+		if h.GetData() != 0.0 {
+			t.Errorf("Hidden layer output was not clamped at zero, got: %f.", h.GetData())
+		}
+	}
+
+	// with every hidden neuron off only the output bias survives
+	// and no gradient flows back to the hidden weights
+	pred, err := m.FeedForward([]float64{1.0, 1.0})
+	if err != nil {
+		t.Fatalf("Model feed forward failed with error: %v", err)
+	}
+	if pred[0].GetData() != 0.25 {
+		t.Errorf("Model output was incorrect, got: %f, want: %f.", pred[0].GetData(), 0.25)
+	}
+
+	pred[0].BackwardPass()
+	for _, p := range hidden.params() {
+		if p.GetGrad() != 0.0 {
+			t.Errorf("Gradient flowed through a clamped hidden neuron, got: %f, want: %f.", p.GetGrad(), 0.0)
+		}
+	}
+}
+
+func TestNewModelActivations(t *testing.T) {
+	modelTestTable := []struct {
+		opts []ModelOption
+		want []Activation
+	}{
+		{nil, []Activation{ActRelu, ActRelu, ActIdentity}},
+		{[]ModelOption{WithActivations(ActTanh, ActElu, ActSigmoid)}, []Activation{ActTanh, ActElu, ActSigmoid}},
+	}
+
+	for _, table := range modelTestTable {
+		m := NewModel(2, []int{4, 3, 1}, table.opts...)
+
+		for idx, l := range m.layers {
+			for _, n := range l.neurons {
+				if n.act != table.want[idx] {
+					t.Errorf("Neuron in layer %d has activation %s, want: %s.", idx, n.act, table.want[idx])
+				}
+			}
+		}
+	}
+}
+
+func TestFeedForwardInputWidth(t *testing.T) {
+	widthTestTable := []struct {
+		inputNum int
+		inputs   []float64
+		fails    bool
+	}{
+		{1, []float64{0.5}, false},
+		{2, []float64{0.5, -0.5}, false},
+		{5, []float64{0.1, 0.2, 0.3, 0.4, 0.5}, false},
+		{3, []float64{0.5, -0.5}, true},
+		{2, []float64{0.1, 0.2, 0.3}, true},
+		{2, nil, true},
+	}
+
+	for _, table := range widthTestTable {
+		m := NewModel(table.inputNum, []int{3, 1})
+		pred, err := m.FeedForward(table.inputs)
+
+		if table.fails && err == nil {
+			t.Errorf("Feeding %d inputs to a model expecting %d should have failed.", len(table.inputs), table.inputNum)
+		}
+		if !table.fails && (err != nil || len(pred) != 1) {
+			t.Errorf("Feeding %d inputs to a model expecting %d failed with error: %v", len(table.inputs), table.inputNum, err)
+		}
+	}
+}
+
+func TestNewModelSeed(t *testing.T) {
+	a := NewModel(2, []int{4, 3, 1}, WithSeed(7))
+	b := NewModel(2, []int{4, 3, 1}, WithRand(rand.New(rand.NewSource(7))))
+	c := NewModel(2, []int{4, 3, 1}, WithSeed(8))
+
+	pa, pb, pc := a.Params(), b.Params(), c.Params()
+	same := true
+	for idx := range pa {
+		if pa[idx].GetData() != pb[idx].GetData() {
+			t.Errorf("Models created with the same seed differ at parameter %d, got: %f, want: %f.", idx, pb[idx].GetData(), pa[idx].GetData())
+		}
+		same = same && pa[idx].GetData() == pc[idx].GetData()
+	}
+	if same {
+		t.Errorf("Models created with different seeds have the same parameters.")
+	}
+}
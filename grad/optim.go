@@ -0,0 +1,209 @@
+package grad
+
+import (
+	"math"
+)
+
+// Optimizer updates a set of parameters (e.g. those returned by Model.Params)
+// using the gradients computed by the last backward pass
+type Optimizer interface {
+	// apply one update to every parameter
+	Step()
+	// reset the gradients of every parameter
+	ZeroGrad()
+	// forget the per parameter state accumulated so far (momentum, moments, ...)
+	Reset()
+	LearningRate() float64
+	SetLearningRate(lr float64)
+}
+
+// fields and methods shared by every optimizer
+type optimizerBase struct {
+	params []*Value
+	lr     float64
+}
+
+func (o *optimizerBase) ZeroGrad() {
+	for _, p := range o.params {
+		p.SetGrad(0.0)
+	}
+}
+
+func (o *optimizerBase) LearningRate() float64 {
+	return o.lr
+}
+
+func (o *optimizerBase) SetLearningRate(lr float64) {
+	o.lr = lr
+}
+
+// stochastic gradient descent with optional (Nesterov) momentum
+type SGD struct {
+	optimizerBase
+	momentum float64
+	nesterov bool
+	velocity map[*Value]float64
+}
+
+// a momentum of 0 gives plain gradient descent, i.e. the same as calling Value.Update on each parameter
+func NewSGD(params []*Value, lr float64, momentum float64, nesterov bool) *SGD {
+	return &SGD{
+		optimizerBase: optimizerBase{params: params, lr: lr},
+		momentum:      momentum,
+		nesterov:      nesterov,
+		velocity:      make(map[*Value]float64),
+	}
+}
+
+func (o *SGD) Step() {
+	for _, p := range o.params {
+		if o.momentum == 0 {
+			p.Update(o.lr)
+			continue
+		}
+
+		v := o.momentum*o.velocity[p] + p.grad
+		o.velocity[p] = v
+
+		if o.nesterov {
+			p.data -= o.lr * (p.grad + o.momentum*v)
+		} else {
+			p.data -= o.lr * v
+		}
+	}
+}
+
+func (o *SGD) Reset() {
+	o.velocity = make(map[*Value]float64)
+}
+
+// first and second moment estimates of a parameter
+type moments struct {
+	m float64
+	v float64
+}
+
+// Adam optimizer (Kingma & Ba, 2014)
+type Adam struct {
+	optimizerBase
+	beta1   float64
+	beta2   float64
+	eps     float64
+	t       int
+	moments map[*Value]*moments
+}
+
+func NewAdam(params []*Value, lr float64, beta1 float64, beta2 float64, eps float64) *Adam {
+	return &Adam{
+		optimizerBase: optimizerBase{params: params, lr: lr},
+		beta1:         beta1,
+		beta2:         beta2,
+		eps:           eps,
+		moments:       make(map[*Value]*moments),
+	}
+}
+
+func (o *Adam) Step() {
+	o.t++
+	for _, p := range o.params {
+		p.data -= o.lr * o.direction(p)
+	}
+}
+
+// bias corrected update direction of a parameter
+func (o *Adam) direction(p *Value) float64 {
+	mo, ok := o.moments[p]
+	if !ok {
+		mo = &moments{}
+		o.moments[p] = mo
+	}
+
+	mo.m = o.beta1*mo.m + (1-o.beta1)*p.grad
+	mo.v = o.beta2*mo.v + (1-o.beta2)*p.grad*p.grad
+	mHat := mo.m / (1 - math.Pow(o.beta1, float64(o.t)))
+	vHat := mo.v / (1 - math.Pow(o.beta2, float64(o.t)))
+
+	return mHat / (math.Sqrt(vHat) + o.eps)
+}
+
+func (o *Adam) Reset() {
+	o.t = 0
+	o.moments = make(map[*Value]*moments)
+}
+
+// Adam with decoupled weight decay (Loshchilov & Hutter, 2017)
+type AdamW struct {
+	Adam
+	weightDecay float64
+}
+
+func NewAdamW(params []*Value, lr float64, beta1 float64, beta2 float64, eps float64, weightDecay float64) *AdamW {
+	return &AdamW{
+		Adam:        *NewAdam(params, lr, beta1, beta2, eps),
+		weightDecay: weightDecay,
+	}
+}
+
+func (o *AdamW) Step() {
+	o.t++
+	for _, p := range o.params {
+		// the decay is applied to the weights directly instead of being added to the gradient
+		p.data -= o.lr * (o.direction(p) + o.weightDecay*p.data)
+	}
+}
+
+// RMSprop optimizer (Hinton, 2012)
+type RMSprop struct {
+	optimizerBase
+	decay  float64
+	eps    float64
+	square map[*Value]float64
+}
+
+func NewRMSprop(params []*Value, lr float64, decay float64, eps float64) *RMSprop {
+	return &RMSprop{
+		optimizerBase: optimizerBase{params: params, lr: lr},
+		decay:         decay,
+		eps:           eps,
+		square:        make(map[*Value]float64),
+	}
+}
+
+func (o *RMSprop) Step() {
+	for _, p := range o.params {
+		sq := o.decay*o.square[p] + (1-o.decay)*p.grad*p.grad
+		o.square[p] = sq
+		p.data -= o.lr * p.grad / (math.Sqrt(sq) + o.eps)
+	}
+}
+
+func (o *RMSprop) Reset() {
+	o.square = make(map[*Value]float64)
+}
+
+// Adagrad optimizer (Duchi et al., 2011)
+type Adagrad struct {
+	optimizerBase
+	eps float64
+	sum map[*Value]float64
+}
+
+func NewAdagrad(params []*Value, lr float64, eps float64) *Adagrad {
+	return &Adagrad{
+		optimizerBase: optimizerBase{params: params, lr: lr},
+		eps:           eps,
+		sum:           make(map[*Value]float64),
+	}
+}
+
+func (o *Adagrad) Step() {
+	for _, p := range o.params {
+		sum := o.sum[p] + p.grad*p.grad
+		o.sum[p] = sum
+		p.data -= o.lr * p.grad / (math.Sqrt(sum) + o.eps)
+	}
+}
+
+func (o *Adagrad) Reset() {
+	o.sum = make(map[*Value]float64)
+}
@@ -0,0 +1,99 @@
+package grad
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOptimizersConverge(t *testing.T) {
+	// every optimizer must bring x close to the minimum of (x - 3)^2
+	optimTestTable := []struct {
+		name  string
+		newFn func([]*Value) Optimizer
+	}{
+		{"SGD", func(ps []*Value) Optimizer { return NewSGD(ps, 0.1, 0.0, false) }},
+		{"SGD momentum", func(ps []*Value) Optimizer { return NewSGD(ps, 0.05, 0.9, false) }},
+		{"SGD nesterov", func(ps []*Value) Optimizer { return NewSGD(ps, 0.05, 0.9, true) }},
+		{"Adam", func(ps []*Value) Optimizer { return NewAdam(ps, 0.1, 0.9, 0.999, 1e-8) }},
+		{"AdamW", func(ps []*Value) Optimizer { return NewAdamW(ps, 0.1, 0.9, 0.999, 1e-8, 0.0) }},
+		{"RMSprop", func(ps []*Value) Optimizer { return NewRMSprop(ps, 0.01, 0.9, 1e-8) }},
+		{"Adagrad", func(ps []*Value) Optimizer { return NewAdagrad(ps, 0.5, 1e-8) }},
+	}
+
+	for _, table := range optimTestTable {
+		x := NewValue(-2.0)
+		opt := table.newFn([]*Value{x})
+
+		for step := 0; step < 1000; step++ {
+			opt.ZeroGrad()
+			loss := x.Sub(NewValue(3.0)).Pow(2)
+			loss.BackwardPass()
+			opt.Step()
+		}
+
+		if math.Abs(x.GetData()-3.0) > 0.01 {
+			t.Errorf("%s did not converge to the minimum, got: %f, want: %f.", table.name, x.GetData(), 3.0)
+		}
+	}
+}
+
+func TestSGDMatchesUpdate(t *testing.T) {
+	a := NewValue(1.5)
+	b := NewValue(1.5)
+	a.SetGrad(0.25)
+	b.SetGrad(0.25)
+
+	a.Update(0.1)
+	NewSGD([]*Value{b}, 0.1, 0.0, false).Step()
+
+	if a.GetData() != b.GetData() {
+		t.Errorf("Plain SGD step differs from Value.Update, got: %f, want: %f.", b.GetData(), a.GetData())
+	}
+}
+
+func TestAdamFirstStep(t *testing.T) {
+	// thanks to bias correction the very first step has the size of the learning rate
+	x := NewValue(1.0)
+	x.SetGrad(123.0)
+	NewAdam([]*Value{x}, 0.01, 0.9, 0.999, 1e-8).Step()
+
+	if math.Abs(x.GetData()-0.99) > 1e-9 {
+		t.Errorf("Adam first step was incorrect, got: %0.9f, want: %0.9f.", x.GetData(), 0.99)
+	}
+}
+
+func TestAdamWDecay(t *testing.T) {
+	// with no gradient only the decoupled weight decay moves the parameter
+	x := NewValue(2.0)
+	opt := NewAdamW([]*Value{x}, 0.1, 0.9, 0.999, 1e-8, 0.5)
+	opt.Step()
+
+	if math.Abs(x.GetData()-1.9) > 1e-9 {
+		t.Errorf("AdamW weight decay was incorrect, got: %0.9f, want: %0.9f.", x.GetData(), 1.9)
+	}
+}
+
+func TestOptimizerResetAndLearningRate(t *testing.T) {
+	x := NewValue(0.0)
+	opt := NewSGD([]*Value{x}, 0.1, 0.9, false)
+
+	x.SetGrad(1.0)
+	opt.Step()
+	opt.Reset()
+
+	// after a reset the accumulated velocity must not contribute to the update
+	before := x.GetData()
+	opt.SetLearningRate(0.5)
+	opt.Step()
+	if math.Abs(before-x.GetData()-0.5) > 1e-9 {
+		t.Errorf("SGD step after reset was incorrect, got: %0.9f, want: %0.9f.", before-x.GetData(), 0.5)
+	}
+	if opt.LearningRate() != 0.5 {
+		t.Errorf("Learning rate was not updated, got: %f, want: %f.", opt.LearningRate(), 0.5)
+	}
+
+	opt.ZeroGrad()
+	if x.GetGrad() != 0.0 {
+		t.Errorf("ZeroGrad did not reset the gradient, got: %f, want: %f.", x.GetGrad(), 0.0)
+	}
+}
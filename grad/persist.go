@@ -0,0 +1,138 @@
+package grad
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// version of the json document produced by Model.Save
+// bump it every time the layout below changes in a non compatible way
+// version 1 stored a boolean nonlin flag instead of the activation name
+const modelFormatVersion = 2
+
+// json representation of a whole model
+type modelJSON struct {
+	Version int         `json:"version"`
+	Inputs  int         `json:"inputs"`
+	Arch    []int       `json:"arch"`
+	Layers  []layerJSON `json:"layers"`
+}
+
+// json representation of a single layer
+type layerJSON struct {
+	Neurons []neuronJSON `json:"neurons"`
+}
+
+// json representation of a single neuron
+type neuronJSON struct {
+	Weights    []float64  `json:"weights"`
+	Bias       float64    `json:"bias"`
+	Activation Activation `json:"activation,omitempty"`
+	Nonlin     bool       `json:"nonlin,omitempty"` // version 1 only
+}
+
+// Save writes the model architecture and all its parameters to w as a json document
+func (m Model) Save(w io.Writer) error {
+	// the same models LoadModel accepts: at least one input and no empty layer
+	if len(m.layers) == 0 || m.inputWidth() < 1 {
+		return fmt.Errorf("gograd: cannot save an empty model")
+	}
+	for li, l := range m.layers {
+		if len(l.neurons) == 0 {
+			return fmt.Errorf("gograd: cannot save a model whose layer %d is empty", li)
+		}
+	}
+
+	doc := modelJSON{
+		Version: modelFormatVersion,
+		Inputs:  m.inputWidth(),
+		Arch:    make([]int, len(m.layers)),
+		Layers:  make([]layerJSON, len(m.layers)),
+	}
+
+	for li, l := range m.layers {
+		doc.Arch[li] = len(l.neurons)
+		doc.Layers[li].Neurons = make([]neuronJSON, len(l.neurons))
+		for ni, n := range l.neurons {
+			weights := make([]float64, len(n.weights))
+			for wi, w := range n.weights {
+				weights[wi] = w.GetData()
+			}
+			doc.Layers[li].Neurons[ni] = neuronJSON{
+				Weights:    weights,
+				Bias:       n.bias.GetData(),
+				Activation: n.act,
+			}
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
+
+// LoadModel reads a json document written by Model.Save and rebuilds the model it describes
+func LoadModel(r io.Reader) (*Model, error) {
+	var doc modelJSON
+
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("gograd: cannot decode model: %v", err)
+	}
+	if doc.Version < 1 || doc.Version > modelFormatVersion {
+		return nil, fmt.Errorf("gograd: unsupported model format version %d, want %d", doc.Version, modelFormatVersion)
+	}
+	if len(doc.Layers) == 0 || len(doc.Layers) != len(doc.Arch) {
+		return nil, fmt.Errorf("gograd: model declares %d layers in arch but contains %d", len(doc.Arch), len(doc.Layers))
+	}
+	if doc.Inputs < 1 {
+		return nil, fmt.Errorf("gograd: model has %d inputs, want at least 1", doc.Inputs)
+	}
+
+	m := Model{layers: nil}
+	// each neuron must have as many weights as the outputs of the previous layer
+	width := doc.Inputs
+	for li, ld := range doc.Layers {
+		if len(ld.Neurons) != doc.Arch[li] {
+			return nil, fmt.Errorf("gograd: layer %d has %d neurons, arch says %d", li, len(ld.Neurons), doc.Arch[li])
+		}
+		if len(ld.Neurons) == 0 {
+			return nil, fmt.Errorf("gograd: layer %d has no neurons", li)
+		}
+
+		l := Layer{neurons: []*Neuron{}}
+		for ni, nd := range ld.Neurons {
+			if len(nd.Weights) != width {
+				return nil, fmt.Errorf("gograd: neuron %d of layer %d has %d weights, want %d", ni, li, len(nd.Weights), width)
+			}
+
+			act := nd.Activation
+			if doc.Version == 1 {
+				// nonlin neurons always used a ReLU
+				act = ActIdentity
+				if nd.Nonlin {
+					act = ActRelu
+				}
+			}
+			if !act.valid() {
+				return nil, fmt.Errorf("gograd: neuron %d of layer %d has unknown activation %q", ni, li, act)
+			}
+
+			n := Neuron{
+				weights: make([]*Value, len(nd.Weights)),
+				bias:    NewValue(nd.Bias),
+				act:     act,
+			}
+			for wi, w := range nd.Weights {
+				n.weights[wi] = NewValue(w)
+			}
+			l.neurons = append(l.neurons, &n)
+		}
+
+		m.layers = append(m.layers, &l)
+		width = len(ld.Neurons)
+	}
+
+	return &m, nil
+}
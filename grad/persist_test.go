@@ -0,0 +1,98 @@
+package grad
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadModel(t *testing.T) {
+	m := NewModel(2, []int{4, 4, 1}, WithActivations(ActTanh, ActGelu, ActSigmoid))
+
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		t.Fatalf("Saving the model failed with error: %v", err)
+	}
+
+	loaded, err := LoadModel(&buf)
+	if err != nil {
+		t.Fatalf("Loading the model failed with error: %v", err)
+	}
+
+	// check that both models have the very same parameters
+	want := m.Params()
+	got := loaded.Params()
+	if len(got) != len(want) {
+		t.Fatalf("The loaded model has a different number of parameters, got:%d, want:%d", len(got), len(want))
+	}
+	for idx := range want {
+		if got[idx].GetData() != want[idx].GetData() {
+			t.Errorf("The parameter at index %d differs, got:%v, want:%v", idx, got[idx].GetData(), want[idx].GetData())
+		}
+	}
+
+	// check that the predictions are the same bit for bit
+	for idx, inp := range GetInputs() {
+		p, _ := m.FeedForward(inp)
+		l, _ := loaded.FeedForward(inp)
+		if p[0].GetData() != l[0].GetData() {
+			t.Errorf("The prediction for input at index %d differs, got:%v, want:%v", idx, l[0].GetData(), p[0].GetData())
+		}
+	}
+}
+
+func TestLoadModelErrors(t *testing.T) {
+	loadTestTable := []struct {
+		name string
+		doc  string
+	}{
+		{"malformed", `{"version": 2,`},
+		{"version", `{"version": 99, "inputs": 1, "arch": [1], "layers": [{"neurons": [{"weights": [1], "bias": 0}]}]}`},
+		{"arch", `{"version": 2, "inputs": 1, "arch": [2], "layers": [{"neurons": [{"weights": [1], "bias": 0, "activation": "relu"}]}]}`},
+		{"weights", `{"version": 2, "inputs": 2, "arch": [1], "layers": [{"neurons": [{"weights": [1], "bias": 0, "activation": "relu"}]}]}`},
+		{"activation", `{"version": 2, "inputs": 1, "arch": [1], "layers": [{"neurons": [{"weights": [1], "bias": 0, "activation": "swish"}]}]}`},
+		{"empty", `{"version": 2, "inputs": 2, "arch": [], "layers": []}`},
+		{"no inputs", `{"version": 2, "inputs": 0, "arch": [1], "layers": [{"neurons": [{"weights": [], "bias": 0, "activation": "relu"}]}]}`},
+		{"empty layer", `{"version": 2, "inputs": 1, "arch": [0], "layers": [{"neurons": []}]}`},
+	}
+
+	for _, table := range loadTestTable {
+		if _, err := LoadModel(strings.NewReader(table.doc)); err == nil {
+			t.Errorf("Loading the %s model document should have failed", table.name)
+		}
+	}
+}
+
+func TestSaveEmptyModel(t *testing.T) {
+	var buf bytes.Buffer
+
+	for _, m := range []Model{
+		{},
+		{layers: []*Layer{{neurons: []*Neuron{}}}},
+		{layers: []*Layer{{neurons: []*Neuron{{bias: NewValue(0.0), act: ActRelu}}}}},
+	} {
+		if err := m.Save(&buf); err == nil {
+			t.Errorf("Saving a model that cannot be loaded should have failed")
+		}
+	}
+}
+
+func TestLoadModelVersion1(t *testing.T) {
+	doc := `{
+		"version": 1,
+		"inputs": 1,
+		"arch": [1, 1],
+		"layers": [
+			{"neurons": [{"weights": [-2], "bias": 0, "nonlin": true}]},
+			{"neurons": [{"weights": [1], "bias": -1, "nonlin": false}]}
+		]
+	}`
+
+	m, err := LoadModel(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Loading a version 1 model failed with error: %v", err)
+	}
+	if m.layers[0].neurons[0].act != ActRelu || m.layers[1].neurons[0].act != ActIdentity {
+		t.Errorf("The nonlin flags of a version 1 model were not converted to activations")
+	}
+}
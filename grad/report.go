@@ -0,0 +1,105 @@
+package grad
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// HyperparScore summarizes the cross validation scores of a single hyperparameter value
+type HyperparScore struct {
+	Hyperpar     float64       `json:"hyperpar"`
+	Mean         float64       `json:"mean"`
+	StdDev       float64       `json:"std_dev"`
+	Folds        []float64     `json:"folds"`
+	TrainingTime time.Duration `json:"training_time_ns"`
+}
+
+func newHyperparScore(h float64, folds []float64, elapsed time.Duration) HyperparScore {
+	return HyperparScore{
+		Hyperpar:     h,
+		Mean:         avgValue(folds),
+		StdDev:       stdDev(folds),
+		Folds:        folds,
+		TrainingTime: elapsed,
+	}
+}
+
+// CVResult is the report of a cross validation search, with the scores
+// of every hyperparameter value in the order they were evaluated
+type CVResult struct {
+	Metric   string          `json:"metric"` // name of the metric of the scores
+	Best     HyperparScore   `json:"best"`
+	Scores   []HyperparScore `json:"scores"`
+	Duration time.Duration   `json:"duration_ns"`
+}
+
+// WriteJSON writes the whole report to w as a json document
+func (r CVResult) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// WriteCSV writes a row for each hyperparameter value to w, with its
+// summary, training time in seconds, whether it is the best and each fold score
+func (r CVResult) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	folds := 0
+	for _, s := range r.Scores {
+		if len(s.Folds) > folds {
+			folds = len(s.Folds)
+		}
+	}
+
+	header := []string{"hyperpar", "mean", "std_dev", "training_time_s", "best"}
+	for i := 1; i <= folds; i++ {
+		header = append(header, "fold_"+strconv.Itoa(i))
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, s := range r.Scores {
+		row := []string{
+			formatFloat(s.Hyperpar),
+			formatFloat(s.Mean),
+			formatFloat(s.StdDev),
+			formatFloat(s.TrainingTime.Seconds()),
+			strconv.FormatBool(s.Hyperpar == r.Best.Hyperpar),
+		}
+		for _, f := range s.Folds {
+			row = append(row, formatFloat(f))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// sample standard deviation (0 when there are less than two values)
+func stdDev(slice []float64) float64 {
+	if len(slice) < 2 {
+		return 0.0
+	}
+
+	mean := avgValue(slice)
+	sum := 0.0
+	for _, el := range slice {
+		sum += (el - mean) * (el - mean)
+	}
+
+	return math.Sqrt(sum / float64(len(slice)-1))
+}
@@ -0,0 +1,179 @@
+package grad
+
+import (
+	"fmt"
+	"math"
+)
+
+// Scheduler decides how the learning rate changes during training
+// it returns a factor which is multiplied by the optimizer's base learning rate
+type Scheduler interface {
+	// factor to apply at step (0 based) out of total steps
+	Factor(step int, total int) float64
+	// record the loss obtained at the last step (only used by schedulers reacting to it)
+	Observe(loss float64)
+	// return an independent scheduler with a fresh state, e.g. to train another model
+	Clone() Scheduler
+}
+
+// SchedulerFunc turns a stateless function like Alpha into a Scheduler
+type SchedulerFunc func(step int, total int) float64
+
+func (f SchedulerFunc) Factor(step int, total int) float64 {
+	return f(step, total)
+}
+
+func (f SchedulerFunc) Observe(loss float64) {}
+
+func (f SchedulerFunc) Clone() Scheduler {
+	return f
+}
+
+// sets the learning rate of opt to the scheduled fraction of base
+func applySchedule(opt Optimizer, s Scheduler, base float64, step int, total int) {
+	opt.SetLearningRate(base * s.Factor(step, total))
+}
+
+// multiplies the learning rate by gamma every stepSize steps
+func NewStepDecay(stepSize int, gamma float64) SchedulerFunc {
+	return func(step int, total int) float64 {
+		return math.Pow(gamma, float64(step/stepSize))
+	}
+}
+
+// multiplies the learning rate by gamma at every step
+func NewExponentialDecay(gamma float64) SchedulerFunc {
+	return func(step int, total int) float64 {
+		return math.Pow(gamma, float64(step))
+	}
+}
+
+// cosine annealing with warm restarts (SGDR, Loshchilov & Hutter, 2016)
+// the first cycle lasts period steps and every following one is mult times longer
+func NewCosineWarmRestarts(period int, mult int, minFactor float64) SchedulerFunc {
+	// cycles that do not grow past a step would never end
+	if period < 1 || mult < 1 {
+		panic(fmt.Sprintf("gograd: cosine restarts need a period and a mult of at least 1, got %d and %d", period, mult))
+	}
+
+	return func(step int, total int) float64 {
+		// find the position of step inside its cycle
+		cur, length := step, period
+		for cur >= length {
+			cur -= length
+			length *= mult
+		}
+
+		return minFactor + (1-minFactor)*(1+math.Cos(math.Pi*float64(cur)/float64(length)))/2
+	}
+}
+
+// linearly increases the learning rate during the first warmup steps
+// and then hands over to after (a constant factor of 1 when nil)
+type LinearWarmup struct {
+	warmup int
+	after  Scheduler
+}
+
+func NewLinearWarmup(warmup int, after Scheduler) *LinearWarmup {
+	return &LinearWarmup{
+		warmup: warmup,
+		after:  after,
+	}
+}
+
+func (s *LinearWarmup) Factor(step int, total int) float64 {
+	if step < s.warmup {
+		return float64(step+1) / float64(s.warmup)
+	}
+	if s.after == nil {
+		return 1.0
+	}
+
+	// the following scheduler starts counting from the end of the warmup
+	return s.after.Factor(step-s.warmup, total-s.warmup)
+}
+
+func (s *LinearWarmup) Observe(loss float64) {
+	if s.after != nil {
+		s.after.Observe(loss)
+	}
+}
+
+func (s *LinearWarmup) Clone() Scheduler {
+	c := LinearWarmup{warmup: s.warmup}
+	if s.after != nil {
+		c.after = s.after.Clone()
+	}
+
+	return &c
+}
+
+// one cycle policy (Smith, 2018): the learning rate rises from 1/div to 1 during
+// the first pctStart fraction of the steps then anneals down to 1/(div*finalDiv)
+func NewOneCycle(pctStart float64, div float64, finalDiv float64) SchedulerFunc {
+	return func(step int, total int) float64 {
+		initial := 1 / div
+		final := initial / finalDiv
+		peak := int(pctStart * float64(total))
+
+		if step < peak {
+			return cosineBetween(initial, 1.0, float64(step)/float64(peak))
+		}
+		if total-1 <= peak {
+			return final
+		}
+
+		return cosineBetween(1.0, final, float64(step-peak)/float64(total-1-peak))
+	}
+}
+
+// cosine interpolation going from start (pct=0) to end (pct=1)
+func cosineBetween(start float64, end float64, pct float64) float64 {
+	return end + (start-end)*(1+math.Cos(math.Pi*math.Min(pct, 1.0)))/2
+}
+
+// multiplies the learning rate by factor every time the observed loss
+// does not improve by at least threshold for more than patience steps
+type ReduceOnPlateau struct {
+	factor    float64
+	patience  int
+	threshold float64
+	minFactor float64
+	current   float64
+	best      float64
+	bad       int
+}
+
+func NewReduceOnPlateau(factor float64, patience int, threshold float64, minFactor float64) *ReduceOnPlateau {
+	return &ReduceOnPlateau{
+		factor:    factor,
+		patience:  patience,
+		threshold: threshold,
+		minFactor: minFactor,
+		current:   1.0,
+		best:      math.Inf(1),
+	}
+}
+
+func (s *ReduceOnPlateau) Factor(step int, total int) float64 {
+	return s.current
+}
+
+func (s *ReduceOnPlateau) Observe(loss float64) {
+	if loss < s.best-s.threshold {
+		s.best = loss
+		s.bad = 0
+		return
+	}
+
+	s.bad++
+	if s.bad > s.patience {
+		s.current = math.Max(s.current*s.factor, s.minFactor)
+		s.bad = 0
+	}
+}
+
+func (s *ReduceOnPlateau) Clone() Scheduler {
+	return NewReduceOnPlateau(s.factor, s.patience, s.threshold, s.minFactor)
+}
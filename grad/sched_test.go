@@ -0,0 +1,105 @@
+package grad
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSchedulers(t *testing.T) {
+	// data table for test
+	schedTestTable := []struct {
+		name  string
+		s     Scheduler
+		step  int
+		total int
+		n     float64
+	}{
+		{"Alpha", SchedulerFunc(Alpha), 0, 100, 1.0},
+		{"Alpha", SchedulerFunc(Alpha), 100, 100, 0.1},
+		{"StepDecay", NewStepDecay(10, 0.5), 9, 100, 1.0},
+		{"StepDecay", NewStepDecay(10, 0.5), 25, 100, 0.25},
+		{"ExponentialDecay", NewExponentialDecay(0.9), 2, 100, 0.81},
+		{"CosineWarmRestarts", NewCosineWarmRestarts(10, 2, 0.0), 0, 100, 1.0},
+		{"CosineWarmRestarts", NewCosineWarmRestarts(10, 2, 0.0), 5, 100, 0.5},
+		{"CosineWarmRestarts", NewCosineWarmRestarts(10, 2, 0.0), 10, 100, 1.0},
+		{"CosineWarmRestarts", NewCosineWarmRestarts(10, 2, 0.0), 20, 100, 0.5},
+		{"CosineWarmRestarts", NewCosineWarmRestarts(10, 2, 0.1), 30, 100, 1.0},
+		{"CosineWarmRestarts", NewCosineWarmRestarts(10, 1, 0.0), 25, 100, 0.5},
+		{"LinearWarmup", NewLinearWarmup(4, nil), 0, 100, 0.25},
+		{"LinearWarmup", NewLinearWarmup(4, nil), 3, 100, 1.0},
+		{"LinearWarmup", NewLinearWarmup(4, NewStepDecay(2, 0.5)), 6, 100, 0.5},
+		{"OneCycle", NewOneCycle(0.25, 10, 100), 0, 101, 0.1},
+		{"OneCycle", NewOneCycle(0.25, 10, 100), 25, 101, 1.0},
+		{"OneCycle", NewOneCycle(0.25, 10, 100), 100, 101, 0.001},
+	}
+
+	for _, table := range schedTestTable {
+		got := table.s.Factor(table.step, table.total)
+
+		if math.Abs(got-table.n) > 1e-9 {
+			t.Errorf("%s factor at step %d was incorrect, got: %f, want: %f.", table.name, table.step, got, table.n)
+		}
+	}
+}
+
+func TestCosineWarmRestartsPanics(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		period int
+		mult   int
+	}{
+		{"zero period", 0, 2},
+		{"zero mult", 10, 0},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s should have panicked", tc.name)
+				}
+			}()
+			NewCosineWarmRestarts(tc.period, tc.mult, 0.0)
+		}()
+	}
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+	s := NewReduceOnPlateau(0.5, 2, 0.0, 0.2)
+
+	// improving losses never reduce the learning rate
+	for _, loss := range []float64{1.0, 0.9, 0.8} {
+		s.Observe(loss)
+	}
+	if s.Factor(3, 100) != 1.0 {
+		t.Errorf("Learning rate was reduced while the loss improved, got: %f, want: %f.", s.Factor(3, 100), 1.0)
+	}
+
+	// three steps without improvement exceed a patience of two
+	for _, loss := range []float64{0.8, 0.85, 0.9} {
+		s.Observe(loss)
+	}
+	if s.Factor(6, 100) != 0.5 {
+		t.Errorf("Learning rate was not reduced on plateau, got: %f, want: %f.", s.Factor(6, 100), 0.5)
+	}
+
+	// the factor never goes below its minimum
+	for i := 0; i < 20; i++ {
+		s.Observe(1.0)
+	}
+	if s.Factor(26, 100) != 0.2 {
+		t.Errorf("Learning rate went below its minimum, got: %f, want: %f.", s.Factor(26, 100), 0.2)
+	}
+
+	// a clone starts from scratch
+	if c := s.Clone(); c.Factor(0, 100) != 1.0 {
+		t.Errorf("Cloned scheduler did not start from a fresh state, got: %f, want: %f.", c.Factor(0, 100), 1.0)
+	}
+}
+
+func TestApplySchedule(t *testing.T) {
+	opt := NewSGD(nil, 0.1, 0.0, false)
+	applySchedule(opt, NewStepDecay(1, 0.5), 0.1, 2, 10)
+
+	if math.Abs(opt.LearningRate()-0.025) > 1e-12 {
+		t.Errorf("Scheduled learning rate was incorrect, got: %f, want: %f.", opt.LearningRate(), 0.025)
+	}
+}
@@ -0,0 +1,575 @@
+package grad
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// names of the hyperparameters understood by Search, any other name
+// in a Space is carried over to the trial's Config untouched
+const (
+	HPLearningRate = "learning_rate" // float64
+	HPLambda       = "lambda"        // float64, L2 regularization
+	HPArch         = "arch"          // []int, network architecture (inputs excluded)
+	HPEpochs       = "epochs"        // int
+	HPBatchSize    = "batch_size"    // int
+	HPActivation   = "activation"    // Activation of the hidden layers
+	HPOptimizer    = "optimizer"     // one of the Opt* names below
+)
+
+// optimizers that can be chosen with HPOptimizer
+const (
+	OptSGD      = "sgd"
+	OptMomentum = "momentum"
+	OptNesterov = "nesterov"
+	OptAdam     = "adam"
+	OptAdamW    = "adamw"
+	OptRMSprop  = "rmsprop"
+	OptAdagrad  = "adagrad"
+)
+
+// values used for the hyperparameters missing from a Space
+var searchDefaults = Config{
+	HPLearningRate: 0.01,
+	HPLambda:       0.0,
+	HPArch:         []int{8, 1},
+	HPEpochs:       10,
+	HPBatchSize:    10,
+	HPActivation:   ActRelu,
+	HPOptimizer:    OptSGD,
+}
+
+// Param describes the values a single hyperparameter can take
+type Param interface {
+	// values tried by grid search
+	grid() []interface{}
+	// value tried by random search
+	sample(rng *rand.Rand) interface{}
+}
+
+// a fixed list of values
+type choiceParam struct {
+	values []interface{}
+}
+
+// Choice is a categorical hyperparameter taking one of values (e.g. activations, architectures or optimizers)
+func Choice(values ...interface{}) Param {
+	if len(values) == 0 {
+		panic("gograd: a choice needs at least one value")
+	}
+
+	return choiceParam{values: values}
+}
+
+// Grid is a numeric hyperparameter taking one of values
+func Grid(values ...float64) Param {
+	if len(values) == 0 {
+		panic("gograd: a grid needs at least one value")
+	}
+
+	c := choiceParam{}
+	for _, v := range values {
+		c.values = append(c.values, v)
+	}
+
+	return c
+}
+
+func (p choiceParam) grid() []interface{} {
+	return p.values
+}
+
+func (p choiceParam) sample(rng *rand.Rand) interface{} {
+	return p.values[rng.Intn(len(p.values))]
+}
+
+// a continuous interval, either linear or logarithmic
+type rangeParam struct {
+	start float64
+	end   float64
+	n     int
+	log   bool
+}
+
+// LinearRange is a hyperparameter between start and end: grid search tries
+// n evenly spaced values while random search draws uniformly from the interval
+func LinearRange(start float64, end float64, n int) Param {
+	checkRange(n)
+
+	return rangeParam{start: start, end: end, n: n}
+}
+
+// LogUniform is a positive hyperparameter between start and end: grid search tries n values evenly
+// spaced on a logarithmic scale while random search draws uniformly from the log of the interval
+// (e.g. learning rates from 1e-4 to 1e-1 are as likely to be close to 1e-4 as to 1e-1)
+func LogUniform(start float64, end float64, n int) Param {
+	checkRange(n)
+	if start <= 0 || end <= 0 {
+		panic(fmt.Sprintf("gograd: a logarithmic range must be positive, got %f to %f", start, end))
+	}
+
+	return rangeParam{start: start, end: end, n: n, log: true}
+}
+
+// grid search needs at least a value to try
+func checkRange(n int) {
+	if n < 1 {
+		panic(fmt.Sprintf("gograd: a range needs at least 1 grid value, got %d", n))
+	}
+}
+
+func (p rangeParam) grid() []interface{} {
+	lo, hi := p.start, p.end
+	if p.log {
+		lo, hi = math.Log(lo), math.Log(hi)
+	}
+
+	values := make([]interface{}, p.n)
+	for i := range values {
+		x := lo
+		if p.n > 1 {
+			x = lo + (hi-lo)*float64(i)/float64(p.n-1)
+		}
+		if p.log {
+			x = math.Exp(x)
+		}
+		values[i] = x
+	}
+
+	return values
+}
+
+func (p rangeParam) sample(rng *rand.Rand) interface{} {
+	if p.log {
+		lo, hi := math.Log(p.start), math.Log(p.end)
+		return math.Exp(lo + rng.Float64()*(hi-lo))
+	}
+
+	return p.start + rng.Float64()*(p.end-p.start)
+}
+
+// Space maps hyperparameter names to the values they can take
+type Space map[string]Param
+
+// sorted names, so that searches always visit the space in the same order
+func (sp Space) names() []string {
+	names := make([]string, 0, len(sp))
+	for name := range sp {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Config holds the value of each hyperparameter of a single trial
+type Config map[string]interface{}
+
+func (c Config) float(name string) float64 {
+	switch v := c.value(name).(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	}
+	panic(fmt.Sprintf("gograd: hyperparameter %s is not a number: %v", name, c[name]))
+}
+
+func (c Config) int(name string) int {
+	switch v := c.value(name).(type) {
+	case int:
+		return v
+	case float64:
+		return int(math.Round(v))
+	}
+	panic(fmt.Sprintf("gograd: hyperparameter %s is not a number: %v", name, c[name]))
+}
+
+// value of name, falling back to its default
+func (c Config) value(name string) interface{} {
+	if v, ok := c[name]; ok {
+		return v
+	}
+
+	return searchDefaults[name]
+}
+
+// model described by the config, for data with the given number of features
+func (c Config) model(inputs int, seed int64) *Model {
+	arch, ok := c.value(HPArch).([]int)
+	if !ok {
+		panic(fmt.Sprintf("gograd: hyperparameter %s is not a []int: %v", HPArch, c[HPArch]))
+	}
+	act, ok := c.value(HPActivation).(Activation)
+	if !ok {
+		panic(fmt.Sprintf("gograd: hyperparameter %s is not an Activation: %v", HPActivation, c[HPActivation]))
+	}
+
+	acts := make([]Activation, len(arch))
+	for l := range acts {
+		acts[l] = act
+	}
+	acts[len(acts)-1] = ActIdentity
+
+	return NewModel(inputs, arch, WithActivations(acts...), WithSeed(seed))
+}
+
+// optimizer described by the config
+func (c Config) optimizer(params []*Value) Optimizer {
+	lr := c.float(HPLearningRate)
+
+	switch c.value(HPOptimizer) {
+	case OptSGD:
+		return NewSGD(params, lr, 0.0, false)
+	case OptMomentum:
+		return NewSGD(params, lr, 0.9, false)
+	case OptNesterov:
+		return NewSGD(params, lr, 0.9, true)
+	case OptAdam:
+		return NewAdam(params, lr, 0.9, 0.999, 1e-8)
+	case OptAdamW:
+		return NewAdamW(params, lr, 0.9, 0.999, 1e-8, 0.01)
+	case OptRMSprop:
+		return NewRMSprop(params, lr, 0.9, 1e-8)
+	case OptAdagrad:
+		return NewAdagrad(params, lr, 1e-8)
+	}
+	panic(fmt.Sprintf("gograd: unknown optimizer %v", c[HPOptimizer]))
+}
+
+// Trial is a configuration together with its holdout accuracy on each fold
+type Trial struct {
+	Config Config
+	Scores []float64
+	Mean   float64
+	Err    error // error of the first fold that could not be trained, such a trial is never the best
+}
+
+// better tells whether t beats o, failed trials lose against every other one (ties are not better)
+func (t Trial) better(o Trial) bool {
+	return t.Err == nil && (o.Err != nil || t.Mean > o.Mean)
+}
+
+// SearchResult contains every trial in the order they were run and the best one
+// (left unset when every trial failed)
+type SearchResult struct {
+	Best   Trial
+	Trials []Trial
+}
+
+// Search looks for the best combination of several hyperparameters using k-fold cross validation
+type Search struct {
+	inputs int
+	lossFn func(*Value, float64) *Value
+	data   [][]float64
+	labels []float64
+	splits []Split
+	space  Space
+	rng    *rand.Rand
+}
+
+func NewSearch(
+	data [][]float64,
+	labels []float64,
+	space Space,
+	lossFunc func(*Value, float64) *Value,
+	k int,
+	seed int64,
+) *Search {
+	s := Search{
+		inputs: len(data[0]),
+		lossFn: lossFunc,
+		data:   data,
+		labels: labels,
+		splits: NewKFold(k).Splits(labels),
+		space:  space,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+
+	return &s
+}
+
+// SetSplitter replaces the k sequential folds with the splits generated by sp
+func (s *Search) SetSplitter(sp Splitter) {
+	s.splits = sp.Splits(s.labels)
+}
+
+// Grid tries every combination of the values of the hyperparameters
+// the error is the one of the first trial that failed, the others are still reported
+func (s *Search) Grid() (SearchResult, error) {
+	names := s.space.names()
+	configs := []Config{{}}
+
+	// cartesian product, one hyperparameter at a time
+	for _, name := range names {
+		var expanded []Config
+		for _, c := range configs {
+			for _, v := range s.space[name].grid() {
+				e := Config{}
+				for k, old := range c {
+					e[k] = old
+				}
+				e[name] = v
+				expanded = append(expanded, e)
+			}
+		}
+		configs = expanded
+	}
+
+	return s.run(configs)
+}
+
+// Random tries the given number of configurations drawn at random from the space
+// the error is the one of the first trial that failed, the others are still reported
+func (s *Search) Random(trials int) (SearchResult, error) {
+	if trials < 1 {
+		return SearchResult{}, fmt.Errorf("gograd: random search needs at least 1 trial, got %d", trials)
+	}
+
+	return s.run(s.sample(trials))
+}
+
+// evaluates each config, the first one with the highest mean wins ties
+func (s *Search) run(configs []Config) (SearchResult, error) {
+	res := SearchResult{Trials: make([]Trial, 0, len(configs))}
+	if len(configs) == 0 {
+		return res, fmt.Errorf("gograd: no configuration to evaluate")
+	}
+	found := false
+	var err error
+
+	for _, c := range configs {
+		t := s.evaluate(c, c.int(HPEpochs))
+		res.Trials = append(res.Trials, t)
+		if t.Err != nil {
+			if err == nil {
+				err = fmt.Errorf("gograd: search trial %v failed: %v", t.Config, t.Err)
+			}
+			continue
+		}
+		if !found || t.better(res.Best) {
+			res.Best, found = t, true
+		}
+	}
+
+	return res, err
+}
+
+// cross validation of a single config trained for the given number of epochs
+// the models are built before starting the goroutines, so that a config of the wrong type
+// panics on the goroutine of the caller, while training errors are reported on the trial
+func (s *Search) evaluate(c Config, epochs int) Trial {
+	t := Trial{Config: c, Scores: make([]float64, len(s.splits))}
+
+	// seeds are drawn before starting the goroutines to keep runs reproducible
+	models := make([]*Model, len(s.splits))
+	trainers := make([]*Trainer, len(s.splits))
+	for i := range s.splits {
+		seed := s.rng.Int63()
+		models[i] = c.model(s.inputs, seed)
+		trainers[i] = NewTrainer(models[i], c.optimizer(models[i].Params()), s.lossFn, epochs, c.int(HPBatchSize), seed)
+		if lambda := c.float(HPLambda); lambda != 0 {
+			trainers[i].SetRegularizer(func(ps []*Value) *Value { return L2(ps, NewValue(lambda)) })
+		}
+	}
+
+	errs := make([]error, len(s.splits))
+	var wg sync.WaitGroup
+	for ki, split := range s.splits {
+		wg.Add(1)
+
+		go func(split Split, idx int) {
+			defer wg.Done()
+
+			holdoutValues, holdoutLabels := subset(s.data, s.labels, split.Holdout)
+			trainingValues, trainingLabels := subset(s.data, s.labels, split.Train)
+
+			if _, errs[idx] = trainers[idx].Fit(trainingValues, trainingLabels); errs[idx] != nil {
+				return
+			}
+
+			t.Scores[idx] = accuracy(models[idx], holdoutValues, holdoutLabels)
+		}(split, ki)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Err = err
+			return t
+		}
+	}
+	t.Mean = avgValue(t.Scores)
+
+	return t
+}
+
+// fraction of inputs whose prediction has the same sign of the label
+func accuracy(m *Model, inputs [][]float64, expectations []float64) float64 {
+	return accuracyScore(outputs(m, inputs), expectations)
+}
+
+// first output of the model for each input
+func outputs(m *Model, inputs [][]float64) []float64 {
+	preds := map2Pred(inputs, m.FeedForward)
+
+	out := make([]float64, len(preds))
+	for idx, p := range preds {
+		out[idx] = p.GetData()
+	}
+
+	return out
+}
+
+// Rung is a round of successive halving: every trial in it was trained for the same number of epochs
+type Rung struct {
+	Epochs int
+	Trials []Trial
+}
+
+// Bracket is a whole run of successive halving, from the first rung to the one with the last survivors
+type Bracket struct {
+	Rungs []Rung
+}
+
+// HalvingResult contains the history of every bracket and the best trial
+// among those trained with the largest budget of their bracket
+type HalvingResult struct {
+	Best     Trial
+	Brackets []Bracket
+}
+
+// SuccessiveHalving draws n random configurations and trains them for minEpochs,
+// then keeps the best 1/eta of them and trains the survivors again for eta times
+// as many epochs, until a single configuration is left
+// HPEpochs is not sampled from the space, every trial reports the epochs of its rung instead
+func (s *Search) SuccessiveHalving(n int, minEpochs int, eta int) HalvingResult {
+	checkHalving(minEpochs, eta)
+	if n < 1 {
+		panic(fmt.Sprintf("gograd: successive halving needs at least 1 configuration, got %d", n))
+	}
+
+	rounds := 1
+	for left := n; left > 1; left = (left + eta - 1) / eta {
+		rounds++
+	}
+	b := s.halve(s.sample(n), minEpochs, eta, rounds)
+
+	return HalvingResult{Best: b.best(), Brackets: []Bracket{b}}
+}
+
+// Hyperband (Li et al., 2017) runs several brackets of successive halving trading
+// off the number of configurations against the epochs given to each of them
+// so that no configuration is ever trained for more than maxEpochs
+// like SuccessiveHalving, every trial reports the epochs of its rung as HPEpochs
+func (s *Search) Hyperband(maxEpochs int, eta int) HalvingResult {
+	checkHalving(maxEpochs, eta)
+
+	// number of brackets minus one, i.e. floor(log_eta(maxEpochs))
+	sMax := 0
+	for r := maxEpochs; r >= eta; r /= eta {
+		sMax++
+	}
+
+	res := HalvingResult{}
+	for br := sMax; br >= 0; br-- {
+		n := int(math.Ceil(float64(sMax+1) / float64(br+1) * math.Pow(float64(eta), float64(br))))
+		epochs := int(float64(maxEpochs) * math.Pow(float64(eta), float64(-br)))
+		if epochs < 1 {
+			epochs = 1
+		}
+
+		b := s.halve(s.sample(n), epochs, eta, br+1)
+		res.Brackets = append(res.Brackets, b)
+
+		best := b.best()
+		if len(res.Brackets) == 1 || best.better(res.Best) {
+			res.Best = best
+		}
+	}
+
+	return res
+}
+
+// with eta below 2 no configuration would ever be discarded (or it would divide by 0)
+func checkHalving(epochs int, eta int) {
+	if eta < 2 {
+		panic(fmt.Sprintf("gograd: halving needs an eta of at least 2, got %d", eta))
+	}
+	if epochs < 1 {
+		panic(fmt.Sprintf("gograd: halving needs at least 1 epoch, got %d", epochs))
+	}
+}
+
+// configurations drawn at random from the space
+func (s *Search) sample(n int) []Config {
+	names := s.space.names()
+	configs := make([]Config, n)
+
+	for i := range configs {
+		configs[i] = Config{}
+		for _, name := range names {
+			configs[i][name] = s.space[name].sample(s.rng)
+		}
+	}
+
+	return configs
+}
+
+// runs at most rounds rungs of successive halving starting from configs
+func (s *Search) halve(configs []Config, epochs int, eta int, rounds int) Bracket {
+	b := Bracket{}
+
+	for r := 0; r < rounds && len(configs) > 0; r++ {
+		rung := Rung{Epochs: epochs}
+		for _, c := range configs {
+			// the trial reports the epochs it was trained for, not the sampled ones
+			tc := Config{}
+			for k, v := range c {
+				tc[k] = v
+			}
+			tc[HPEpochs] = epochs
+			rung.Trials = append(rung.Trials, s.evaluate(tc, epochs))
+		}
+		b.Rungs = append(b.Rungs, rung)
+
+		// keep the best 1/eta (at least one), ties are won by the first trial
+		sorted := make([]Trial, len(rung.Trials))
+		copy(sorted, rung.Trials)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].better(sorted[j])
+		})
+		keep := len(sorted) / eta
+		if keep < 1 {
+			keep = 1
+		}
+		if keep == len(configs) {
+			break
+		}
+
+		var survivors []Config
+		for _, t := range sorted[:keep] {
+			survivors = append(survivors, t.Config)
+		}
+		configs = survivors
+		epochs *= eta
+	}
+
+	return b
+}
+
+// best trial of the last rung, i.e. the one trained for the most epochs
+func (b Bracket) best() Trial {
+	last := b.Rungs[len(b.Rungs)-1]
+	best := last.Trials[0]
+	for _, t := range last.Trials[1:] {
+		if t.better(best) {
+			best = t
+		}
+	}
+
+	return best
+}
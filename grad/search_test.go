@@ -0,0 +1,290 @@
+package grad
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestParams(t *testing.T) {
+	lin := LinearRange(0.0, 1.0, 5).grid()
+	for idx, want := range []float64{0.0, 0.25, 0.5, 0.75, 1.0} {
+		if math.Abs(lin[idx].(float64)-want) > 1e-12 {
+			t.Errorf("LinearRange grid value at index %d was incorrect, got: %v, want: %f.", idx, lin[idx], want)
+		}
+	}
+
+	log := LogUniform(0.001, 1.0, 4).grid()
+	for idx, want := range []float64{0.001, 0.01, 0.1, 1.0} {
+		if math.Abs(log[idx].(float64)-want) > 1e-12 {
+			t.Errorf("LogUniform grid value at index %d was incorrect, got: %v, want: %f.", idx, log[idx], want)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		if v := LogUniform(0.001, 1.0, 4).sample(rng).(float64); v < 0.001 || v > 1.0 {
+			t.Errorf("LogUniform sample out of range, got: %f.", v)
+		}
+		if v := LinearRange(-1.0, 1.0, 4).sample(rng).(float64); v < -1.0 || v > 1.0 {
+			t.Errorf("LinearRange sample out of range, got: %f.", v)
+		}
+		if v := Choice(ActTanh, ActRelu).sample(rng); v != ActTanh && v != ActRelu {
+			t.Errorf("Choice sample is not one of the choices, got: %v.", v)
+		}
+	}
+}
+
+// small search space used by the tests below
+func newTestSearch(seed int64) *Search {
+	space := Space{
+		HPLearningRate: LogUniform(0.001, 0.1, 2),
+		HPArch:         Choice([]int{4, 1}, []int{4, 4, 1}),
+		HPActivation:   Choice(ActRelu, ActTanh),
+		HPOptimizer:    Choice(OptAdam),
+		HPEpochs:       Choice(2),
+	}
+
+	return NewSearch(GetInputs()[:40], GetLabels()[:40], space, MSE, 2, seed)
+}
+
+func TestSearchGrid(t *testing.T) {
+	res, err := newTestSearch(1).Grid()
+	if err != nil {
+		t.Fatalf("Grid search failed with error: %v", err)
+	}
+
+	// 2 learning rates * 2 architectures * 2 activations
+	if len(res.Trials) != 8 {
+		t.Fatalf("Grid search ran the wrong number of trials, got:%d, want:%d", len(res.Trials), 8)
+	}
+
+	seen := map[string]bool{}
+	for _, tr := range res.Trials {
+		if len(tr.Scores) != 2 {
+			t.Errorf("Trial has the wrong number of fold scores, got:%d, want:%d", len(tr.Scores), 2)
+		}
+		if tr.Mean > res.Best.Mean {
+			t.Errorf("Trial %v scored better than the best one, got:%f, best:%f", tr.Config, tr.Mean, res.Best.Mean)
+		}
+		seen[fmt.Sprint(tr.Config)] = true
+	}
+	if len(seen) != 8 {
+		t.Errorf("Grid search repeated some configurations, got %d distinct ones, want:%d", len(seen), 8)
+	}
+}
+
+func TestSearchRandom(t *testing.T) {
+	a, errA := newTestSearch(2).Random(3)
+	b, errB := newTestSearch(2).Random(3)
+	if errA != nil || errB != nil {
+		t.Fatalf("Random search failed with errors: %v, %v", errA, errB)
+	}
+
+	if len(a.Trials) != 3 {
+		t.Fatalf("Random search ran the wrong number of trials, got:%d, want:%d", len(a.Trials), 3)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Random searches with the same seed produced different results, got:%v, want:%v", b, a)
+	}
+}
+
+func TestSuccessiveHalving(t *testing.T) {
+	res := newTestSearch(3).SuccessiveHalving(4, 1, 2)
+
+	if len(res.Brackets) != 1 {
+		t.Fatalf("Successive halving ran the wrong number of brackets, got:%d, want:%d", len(res.Brackets), 1)
+	}
+
+	// 4 configurations for 1 epoch, 2 for 2 epochs and 1 for 4 epochs
+	rungs := res.Brackets[0].Rungs
+	wantTrials := []int{4, 2, 1}
+	wantEpochs := []int{1, 2, 4}
+	if len(rungs) != len(wantTrials) {
+		t.Fatalf("Successive halving ran the wrong number of rungs, got:%d, want:%d", len(rungs), len(wantTrials))
+	}
+	for idx, r := range rungs {
+		if len(r.Trials) != wantTrials[idx] || r.Epochs != wantEpochs[idx] {
+			t.Errorf("Rung %d was incorrect, got:%d trials for %d epochs, want:%d trials for %d epochs",
+				idx,
+				len(r.Trials),
+				r.Epochs,
+				wantTrials[idx],
+				wantEpochs[idx],
+			)
+		}
+	}
+
+	// survivors come from the best configurations of the previous rung
+	for idx := 1; idx < len(rungs); idx++ {
+		worst := math.Inf(1)
+		for _, tr := range rungs[idx].Trials {
+			for _, prev := range rungs[idx-1].Trials {
+				if sameBesidesEpochs(prev.Config, tr.Config) {
+					worst = math.Min(worst, prev.Mean)
+				}
+			}
+		}
+		for _, prev := range rungs[idx-1].Trials {
+			survived := false
+			for _, tr := range rungs[idx].Trials {
+				survived = survived || sameBesidesEpochs(prev.Config, tr.Config)
+			}
+			if !survived && prev.Mean > worst {
+				t.Errorf("Rung %d dropped a configuration scoring better than a survivor, got:%f > %f", idx-1, prev.Mean, worst)
+			}
+		}
+	}
+
+	// the configs report the epochs they were trained for
+	for idx, r := range rungs {
+		for _, tr := range r.Trials {
+			if got := tr.Config.int(HPEpochs); got != r.Epochs {
+				t.Errorf("Trial of rung %d reports the wrong epochs, got:%d, want:%d", idx, got, r.Epochs)
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(res.Best, rungs[2].Trials[0]) {
+		t.Errorf("The best trial is not the one trained with the largest budget, got:%v, want:%v", res.Best, rungs[2].Trials[0])
+	}
+}
+
+// compares two configs of different rungs, whose epochs differ
+func sameBesidesEpochs(a Config, b Config) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if k != HPEpochs && !reflect.DeepEqual(v, b[k]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestHyperband(t *testing.T) {
+	res := newTestSearch(4).Hyperband(3, 3)
+
+	// two brackets: 3 configurations for 1 epoch then 1 for 3 epochs, and 2 configurations for 3 epochs
+	want := [][][2]int{
+		{{3, 1}, {1, 3}},
+		{{2, 3}},
+	}
+	if len(res.Brackets) != len(want) {
+		t.Fatalf("Hyperband ran the wrong number of brackets, got:%d, want:%d", len(res.Brackets), len(want))
+	}
+	for bi, b := range res.Brackets {
+		if len(b.Rungs) != len(want[bi]) {
+			t.Errorf("Bracket %d has the wrong number of rungs, got:%d, want:%d", bi, len(b.Rungs), len(want[bi]))
+			continue
+		}
+		for ri, r := range b.Rungs {
+			if len(r.Trials) != want[bi][ri][0] || r.Epochs != want[bi][ri][1] {
+				t.Errorf("Rung %d of bracket %d was incorrect, got:%d trials for %d epochs, want:%d trials for %d epochs",
+					ri,
+					bi,
+					len(r.Trials),
+					r.Epochs,
+					want[bi][ri][0],
+					want[bi][ri][1],
+				)
+			}
+		}
+	}
+
+	for _, b := range res.Brackets {
+		if b.best().Mean > res.Best.Mean {
+			t.Errorf("A bracket scored better than the best trial, got:%f > %f", b.best().Mean, res.Best.Mean)
+		}
+	}
+}
+
+func TestHalvingPanics(t *testing.T) {
+	s := newTestSearch(5)
+
+	for _, tc := range []struct {
+		name string
+		f    func()
+	}{
+		{"halving with eta 1", func() { s.SuccessiveHalving(4, 1, 1) }},
+		{"halving with eta 0", func() { s.SuccessiveHalving(4, 1, 0) }},
+		{"halving without configurations", func() { s.SuccessiveHalving(0, 1, 2) }},
+		{"halving without epochs", func() { s.SuccessiveHalving(4, 0, 2) }},
+		{"hyperband with eta 1", func() { s.Hyperband(3, 1) }},
+		{"hyperband with eta 0", func() { s.Hyperband(3, 0) }},
+		{"hyperband without epochs", func() { s.Hyperband(0, 3) }},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s should have panicked", tc.name)
+				}
+			}()
+			tc.f()
+		}()
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	// a batch size of 0 cannot be trained, the other configuration still can
+	s := newTestSearch(6)
+	s.space[HPBatchSize] = Choice(0, 10)
+	s.space[HPArch] = Choice([]int{4, 1})
+	s.space[HPActivation] = Choice(ActRelu)
+	s.space[HPLearningRate] = Choice(0.01)
+
+	res, err := s.Grid()
+	if err == nil {
+		t.Errorf("A search with a failing trial did not return an error")
+	}
+	if len(res.Trials) != 2 || res.Trials[0].Err == nil || res.Trials[1].Err != nil {
+		t.Fatalf("The failing trial was not reported, got:%v", res.Trials)
+	}
+	if !reflect.DeepEqual(res.Best, res.Trials[1]) {
+		t.Errorf("A failed trial was chosen as the best, got:%v, want:%v", res.Best, res.Trials[1])
+	}
+
+	// every trial failed: no best trial
+	s.space[HPBatchSize] = Choice(0)
+	res, err = s.Random(2)
+	if err == nil || res.Best.Config != nil {
+		t.Errorf("A search where every trial failed reported a best trial, got:%v, error:%v", res.Best, err)
+	}
+
+	if _, err := s.Random(0); err == nil {
+		t.Errorf("A random search without trials did not fail")
+	}
+	if _, err := s.Random(-1); err == nil {
+		t.Errorf("A random search with a negative number of trials did not fail")
+	}
+	if _, err := s.run(nil); err == nil {
+		t.Errorf("A search without configurations did not fail")
+	}
+}
+
+func TestParamPanics(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		f    func()
+	}{
+		{"linear range without values", func() { LinearRange(0.0, 1.0, 0) }},
+		{"linear range with negative values", func() { LinearRange(0.0, 1.0, -1) }},
+		{"log range without values", func() { LogUniform(0.001, 1.0, 0) }},
+		{"log range from 0", func() { LogUniform(0.0, 1.0, 2) }},
+		{"empty choice", func() { Choice() }},
+		{"empty grid", func() { Grid() }},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s should have panicked", tc.name)
+				}
+			}()
+			tc.f()
+		}()
+	}
+}
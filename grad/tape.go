@@ -0,0 +1,201 @@
+package grad
+
+import (
+	"fmt"
+)
+
+// Tape is a computation traced once into a fixed graph, which can then be executed again
+// for new values of its named placeholder inputs: Forward and Backward reuse the same nodes
+// and closures, and the topological order computed when tracing
+// values that are not placeholders (e.g. the parameters of a model) are read at every Forward,
+// so they can be updated between executions, but the graph itself must not depend on the inputs
+// (e.g. no branching on their values while tracing)
+type Tape struct {
+	names   []string
+	index   map[string]int
+	inputs  []*Value // placeholders, in the order of names
+	outputs []*Value
+	nodes   []*Value // every node the outputs depend on, children first
+}
+
+// Trace calls build with a placeholder for each name, all set to 0,
+// and compiles the graph of the outputs it returns
+func Trace(names []string, build func(inputs map[string]*Value) []*Value) *Tape {
+	t := Tape{
+		names:  names,
+		index:  make(map[string]int, len(names)),
+		inputs: make([]*Value, len(names)),
+	}
+
+	placeholders := make(map[string]*Value, len(names))
+	for idx, name := range names {
+		if _, ok := t.index[name]; ok {
+			panic(fmt.Sprintf("gograd: placeholder %q declared twice", name))
+		}
+		t.index[name] = idx
+		t.inputs[idx] = NewValue(0.0)
+		placeholders[name] = t.inputs[idx]
+	}
+
+	t.outputs = build(placeholders)
+
+	visited := map[*Value]bool{}
+	for _, out := range t.outputs {
+		topologicalSort(out, &visited, &t.nodes)
+	}
+
+	return &t
+}
+
+// Compile traces the model with a placeholder for each input, named x0, x1 and so on,
+// its outputs being the ones of the model
+func (m *Model) Compile() *Tape {
+	names := make([]string, m.inputWidth())
+	for i := range names {
+		names[i] = fmt.Sprintf("x%d", i)
+	}
+
+	return Trace(names, func(inputs map[string]*Value) []*Value {
+		inps := make([]*Value, len(names))
+		for i, name := range names {
+			inps[i] = inputs[name]
+		}
+		for _, l := range m.layers {
+			inps = l.feedForward(inps)
+		}
+		return inps
+	})
+}
+
+// Set changes the value of the placeholder with the given name
+func (t *Tape) Set(name string, x float64) error {
+	idx, ok := t.index[name]
+	if !ok {
+		return fmt.Errorf("gograd: unknown placeholder %q", name)
+	}
+	t.inputs[idx].data = x
+
+	return nil
+}
+
+// SetInputs changes the value of every placeholder, in the order they were declared
+func (t *Tape) SetInputs(xs []float64) error {
+	if len(xs) != len(t.inputs) {
+		return fmt.Errorf("gograd: tape expects %d inputs, got %d", len(t.inputs), len(xs))
+	}
+	for i, x := range xs {
+		t.inputs[i].data = x
+	}
+
+	return nil
+}
+
+// Forward computes again every node from the current inputs
+func (t *Tape) Forward() {
+	for _, node := range t.nodes {
+		node.recompute()
+	}
+}
+
+// Outputs returns the output nodes, whose data is the one computed by the last Forward
+func (t *Tape) Outputs() []*Value {
+	return t.outputs
+}
+
+// Input returns the placeholder with the given name (nil when there is none),
+// after Backward its gradient is the one of the outputs with respect to that input
+func (t *Tape) Input(name string) *Value {
+	idx, ok := t.index[name]
+	if !ok {
+		return nil
+	}
+
+	return t.inputs[idx]
+}
+
+// Backward propagates the gradients of the outputs, seeds[i] being the one of the i-th output
+// (all of them 1 when no seed is given, e.g. for a single output to be minimized)
+// the gradients of the inner nodes and of the placeholders are reset first, while the ones of the
+// other leaves (e.g. parameters) accumulate like they do with BackwardPass
+func (t *Tape) Backward(seeds ...float64) {
+	if len(seeds) != 0 && len(seeds) != len(t.outputs) {
+		panic(fmt.Sprintf("gograd: tape has %d outputs, got %d seeds", len(t.outputs), len(seeds)))
+	}
+
+	for _, node := range t.nodes {
+		if len(node.children) > 0 {
+			node.grad = 0
+		}
+	}
+	for _, in := range t.inputs {
+		in.grad = 0
+	}
+	for i, out := range t.outputs {
+		if len(seeds) == 0 {
+			out.grad += 1
+		} else {
+			out.grad += seeds[i]
+		}
+	}
+
+	for i := len(t.nodes) - 1; i >= 0; i-- {
+		t.nodes[i].backward()
+	}
+}
+
+// a model and its regularizer compiled once, to train them without building a new graph at each step
+type compiledModel struct {
+	model *Tape
+	reg   *Tape // nil without regularization
+}
+
+// reg receives the parameters of m, it is traced once so it must not depend on anything else that changes
+func compileModel(m *Model, reg func([]*Value) *Value) *compiledModel {
+	c := compiledModel{model: m.Compile()}
+	if reg != nil {
+		params := m.Params()
+		c.reg = Trace(nil, func(map[string]*Value) []*Value {
+			return []*Value{reg(params)}
+		})
+	}
+
+	return &c
+}
+
+// adds to the parameters the gradient of weight * lossFn(output, label), returning the output and the loss
+// labels are float64 for loss functions, so the loss is computed on a small graph of its own
+// starting from a copy of the output of the model
+func (c *compiledModel) sampleGrad(
+	inputs []float64,
+	label float64,
+	lossFn func(*Value, float64) *Value,
+	weight float64,
+) (float64, float64, error) {
+	if err := c.model.SetInputs(inputs); err != nil {
+		return 0, 0, err
+	}
+	c.model.Forward()
+
+	outs := c.model.Outputs()
+	if len(outs) != 1 {
+		return 0, 0, fmt.Errorf("gograd: training needs a model with a single output, got %d", len(outs))
+	}
+
+	pred := NewValue(outs[0].data)
+	loss := lossFn(pred, label)
+	loss.BackwardPass()
+	c.model.Backward(weight * pred.grad)
+
+	return outs[0].data, loss.data, nil
+}
+
+// adds to the parameters the gradient of the regularization, returning its value
+func (c *compiledModel) regGrad() float64 {
+	if c.reg == nil {
+		return 0.0
+	}
+	c.reg.Forward()
+	c.reg.Backward()
+
+	return c.reg.Outputs()[0].data
+}
@@ -0,0 +1,180 @@
+package grad
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTape(t *testing.T) {
+	// every op, so that all of them are computed again by Forward
+	build := func(x *Value, y *Value) *Value {
+		a := x.Mul(y).Add(x.Pow(3)).Sub(y.Div(x)).Tanh()
+		b := x.Sigmoid().Mul(y.Relu()).Add(x.LeakyRelu(0.01)).Add(y.Elu(1.0))
+		c := x.Gelu().Add(y.Softplus()).Add(x.Identity()).Add(y.Exp()).Add(x.Abs().Log()).Add(y.Abs().Sqrt())
+		d := x.Sin().Mul(y.Cos()).Add(x.Max(y)).Add(x.Min(y)).Add(y.Clamp(-0.5, 0.5)).Neg()
+		return a.Add(b).Add(c).Add(d)
+	}
+
+	tape := Trace([]string{"x", "y"}, func(in map[string]*Value) []*Value {
+		return []*Value{build(in["x"], in["y"])}
+	})
+
+	for _, point := range [][2]float64{{0.7, -1.2}, {-0.4, 0.3}, {1.5, 2.0}} {
+		if err := tape.Set("x", point[0]); err != nil {
+			t.Fatalf("Setting a placeholder failed with error: %v", err)
+		}
+		if err := tape.Set("y", point[1]); err != nil {
+			t.Fatalf("Setting a placeholder failed with error: %v", err)
+		}
+		tape.Forward()
+		tape.Backward()
+
+		x, y := NewValue(point[0]), NewValue(point[1])
+		want := build(x, y)
+		want.BackwardPass()
+
+		if got := tape.Outputs()[0].GetData(); math.Abs(got-want.GetData()) > 1e-12 {
+			t.Errorf("Tape output at %v was incorrect, got:%f, want:%f", point, got, want.GetData())
+		}
+		if got := tape.Input("x").GetGrad(); math.Abs(got-x.GetGrad()) > 1e-12 {
+			t.Errorf("Tape gradient of x at %v was incorrect, got:%f, want:%f", point, got, x.GetGrad())
+		}
+		if got := tape.Input("y").GetGrad(); math.Abs(got-y.GetGrad()) > 1e-12 {
+			t.Errorf("Tape gradient of y at %v was incorrect, got:%f, want:%f", point, got, y.GetGrad())
+		}
+	}
+}
+
+func TestTapeErrors(t *testing.T) {
+	tape := Trace([]string{"a", "b"}, func(in map[string]*Value) []*Value {
+		return []*Value{in["a"].Mul(in["b"])}
+	})
+
+	if err := tape.Set("c", 1.0); err == nil {
+		t.Errorf("Setting an unknown placeholder should have failed")
+	}
+	if err := tape.SetInputs([]float64{1.0}); err == nil {
+		t.Errorf("Setting the wrong number of inputs should have failed")
+	}
+	if tape.Input("c") != nil {
+		t.Errorf("An unknown placeholder should be nil")
+	}
+
+	// an operation that cannot be computed again must not leave a stale output
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Running an operation without a forward computation should have panicked")
+			}
+		}()
+		stale := Trace([]string{"a"}, func(in map[string]*Value) []*Value {
+			out := &Value{op: NewSubtraction(), children: NewSet(in["a"], nil), backward: func() {}}
+			out.operands[0] = in["a"]
+			return []*Value{out}
+		})
+		stale.Forward()
+	}()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Declaring a placeholder twice should have panicked")
+		}
+	}()
+	Trace([]string{"a", "a"}, func(in map[string]*Value) []*Value { return nil })
+}
+
+func TestModelCompile(t *testing.T) {
+	m := NewModel(2, []int{4, 4, 1}, WithActivations(ActTanh, ActRelu, ActIdentity), WithSeed(21))
+	inputs, labels := GetInputs()[:8], GetLabels()[:8]
+	lambda := NewValue(0.001)
+
+	// gradients of the mean loss of a batch plus L2, built as a whole graph
+	m.ZeroGrad()
+	loss := NewValue(0.0)
+	for idx, inp := range inputs {
+		pred, _ := m.FeedForward(inp)
+		loss = loss.Add(Hinge(pred[0], labels[idx]))
+	}
+	loss = loss.Div(len(inputs)).Add(L2(m.Params(), lambda))
+	loss.BackwardPass()
+	want := make([]float64, len(m.Params()))
+	for i, p := range m.Params() {
+		want[i] = p.GetGrad()
+	}
+
+	// and the same ones with the compiled model, twice to check that nothing is left from the first run
+	c := compileModel(m, func(ps []*Value) *Value { return L2(ps, lambda) })
+	for run := 0; run < 2; run++ {
+		m.ZeroGrad()
+		got := 0.0
+		for idx, inp := range inputs {
+			pred, l, err := c.sampleGrad(inp, labels[idx], Hinge, 1/float64(len(inputs)))
+			if err != nil {
+				t.Fatalf("Compiled training step failed with error: %v", err)
+			}
+			out, _ := m.FeedForward(inp)
+			if math.Abs(pred-out[0].GetData()) > 1e-12 {
+				t.Errorf("Compiled model output was incorrect, got:%f, want:%f", pred, out[0].GetData())
+			}
+			got += l / float64(len(inputs))
+		}
+		got += c.regGrad()
+
+		if math.Abs(got-loss.GetData()) > 1e-12 {
+			t.Errorf("Compiled loss was incorrect, got:%f, want:%f", got, loss.GetData())
+		}
+		for i, p := range m.Params() {
+			if math.Abs(p.GetGrad()-want[i]) > 1e-12 {
+				t.Errorf("Compiled gradient of parameter %d was incorrect, got:%f, want:%f", i, p.GetGrad(), want[i])
+			}
+		}
+	}
+
+	if _, _, err := c.sampleGrad([]float64{1.0}, 1.0, Hinge, 1.0); err == nil {
+		t.Errorf("A compiled step with inputs of the wrong width should have failed")
+	}
+}
+
+func TestTapeNoAllocations(t *testing.T) {
+	m := NewModel(2, []int{8, 8, 1}, WithSeed(3))
+	tape := m.Compile()
+	inputs := GetInputs()[0]
+
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = tape.SetInputs(inputs)
+		tape.Forward()
+		tape.Backward()
+	})
+	if allocs != 0 {
+		t.Errorf("Executing a compiled tape allocated memory, got:%f allocations per run", allocs)
+	}
+}
+
+// training steps on a batch of 10 samples, with a new graph each time and with a compiled tape
+func BenchmarkGraphStep(b *testing.B) {
+	m := NewModel(2, []int{16, 16, 1}, WithSeed(1))
+	inputs, labels := GetInputs()[:10], GetLabels()[:10]
+
+	for i := 0; i < b.N; i++ {
+		m.ZeroGrad()
+		loss := NewValue(0.0)
+		for idx, inp := range inputs {
+			pred, _ := m.FeedForward(inp)
+			loss = loss.Add(MSE(pred[0], labels[idx]))
+		}
+		loss.Div(len(inputs)).BackwardPass()
+	}
+}
+
+func BenchmarkTapeStep(b *testing.B) {
+	m := NewModel(2, []int{16, 16, 1}, WithSeed(1))
+	inputs, labels := GetInputs()[:10], GetLabels()[:10]
+	c := compileModel(m, nil)
+
+	for i := 0; i < b.N; i++ {
+		m.ZeroGrad()
+		for idx, inp := range inputs {
+			_, _, _ = c.sampleGrad(inp, labels[idx], MSE, 0.1)
+		}
+	}
+}
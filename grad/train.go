@@ -0,0 +1,138 @@
+package grad
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// statistics collected at the end of each training epoch
+type EpochStats struct {
+	Epoch    int
+	Loss     float64 // mean loss over every sample (regularization excluded)
+	Accuracy float64 // fraction of samples whose prediction has the same sign of the label
+}
+
+// Trainer fits a model to a whole dataset using mini-batches
+type Trainer struct {
+	model      *Model
+	opt        Optimizer
+	lossFn     func(*Value, float64) *Value
+	sched      Scheduler
+	regularize func([]*Value) *Value
+	epochs     int
+	batchSize  int
+	rng        *rand.Rand
+}
+
+// the seed drives the order in which the samples are visited at every epoch
+func NewTrainer(
+	m *Model,
+	opt Optimizer,
+	lossFunc func(*Value, float64) *Value,
+	epochs int,
+	batchSize int,
+	seed int64,
+) *Trainer {
+	t := Trainer{
+		model:     m,
+		opt:       opt,
+		lossFn:    lossFunc,
+		epochs:    epochs,
+		batchSize: batchSize,
+		rng:       rand.New(rand.NewSource(seed)),
+	}
+
+	return &t
+}
+
+// SetScheduler makes the learning rate follow s, one step for each mini-batch
+func (t *Trainer) SetScheduler(s Scheduler) {
+	t.sched = s
+}
+
+// SetRegularizer adds f(model params) to the loss of each mini-batch, e.g. to use L2
+// f is traced once when training starts, so it must depend on the params only
+func (t *Trainer) SetRegularizer(f func([]*Value) *Value) {
+	t.regularize = f
+}
+
+// Fit trains the model on inputs and labels and returns the statistics of every epoch
+func (t *Trainer) Fit(inputs [][]float64, labels []float64) ([]EpochStats, error) {
+	if len(inputs) != len(labels) {
+		return nil, fmt.Errorf("gograd: got %d inputs and %d labels", len(inputs), len(labels))
+	}
+	if len(inputs) == 0 || t.batchSize < 1 {
+		return nil, fmt.Errorf("gograd: cannot train on %d samples with batches of %d", len(inputs), t.batchSize)
+	}
+	// checked up front, so that a bad sample does not leave the model half trained
+	want := t.model.inputWidth()
+	for idx, inp := range inputs {
+		if len(inp) != want {
+			return nil, fmt.Errorf("gograd: sample at index %d has %d features, model expects %d", idx, len(inp), want)
+		}
+	}
+
+	batches := (len(inputs) + t.batchSize - 1) / t.batchSize
+	steps := t.epochs * batches
+	base := t.opt.LearningRate()
+	history := make([]EpochStats, 0, t.epochs)
+
+	// the model is traced once and executed again for every sample
+	compiled := compileModel(t.model, t.regularize)
+
+	order := make([]int, len(inputs))
+	for i := range order {
+		order[i] = i
+	}
+
+	for epoch := 0; epoch < t.epochs; epoch++ {
+		t.rng.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+
+		lossSum, hits := 0.0, 0.0
+		for b := 0; b < batches; b++ {
+			start := b * t.batchSize
+			end := start + t.batchSize
+			if end > len(order) {
+				end = len(order)
+			}
+
+			// prepping
+			t.model.ZeroGrad()
+			if t.sched != nil {
+				applySchedule(t.opt, t.sched, base, epoch*batches+b, steps)
+			}
+
+			// backward pass of the mean loss of the batch, one sample at a time
+			n := float64(end - start)
+			loss := 0.0
+			for _, idx := range order[start:end] {
+				pred, l, err := compiled.sampleGrad(inputs[idx], labels[idx], t.lossFn, 1/n)
+				if err != nil {
+					return history, err
+				}
+				loss += l / n
+
+				if (pred > 0.0) == (labels[idx] > 0.0) {
+					hits += 1.0
+				}
+			}
+			lossSum += loss * n
+			totLoss := loss + compiled.regGrad()
+
+			t.opt.Step()
+			if t.sched != nil {
+				t.sched.Observe(totLoss)
+			}
+		}
+
+		history = append(history, EpochStats{
+			Epoch:    epoch + 1,
+			Loss:     lossSum / float64(len(inputs)),
+			Accuracy: hits / float64(len(inputs)),
+		})
+	}
+
+	return history, nil
+}
@@ -0,0 +1,63 @@
+package grad
+
+import (
+	"testing"
+)
+
+func TestTrainerFit(t *testing.T) {
+	m := NewModel(2, []int{8, 8, 1}, WithSeed(42))
+	opt := NewAdam(m.Params(), 0.01, 0.9, 0.999, 1e-8)
+	tr := NewTrainer(m, opt, Hinge, 40, 10, 42)
+	tr.SetScheduler(NewStepDecay(100, 0.5))
+	tr.SetRegularizer(func(ps []*Value) *Value { return L2(ps, NewValue(0.0001)) })
+
+	history, err := tr.Fit(GetInputs(), GetLabels())
+	if err != nil {
+		t.Fatalf("Training failed with error: %v", err)
+	}
+	if len(history) != 40 {
+		t.Fatalf("The training history has the wrong number of epochs, got:%d, want:%d", len(history), 40)
+	}
+
+	first, last := history[0], history[len(history)-1]
+	if last.Epoch != 40 {
+		t.Errorf("The last epoch has the wrong number, got:%d, want:%d", last.Epoch, 40)
+	}
+	if last.Loss >= first.Loss {
+		t.Errorf("The loss did not decrease during training, first:%f, last:%f", first.Loss, last.Loss)
+	}
+	if last.Accuracy < 0.8 {
+		t.Errorf("The accuracy after training is too low, got:%f", last.Accuracy)
+	}
+}
+
+func TestTrainerErrors(t *testing.T) {
+	m := NewModel(2, []int{1})
+	opt := NewSGD(m.Params(), 0.01, 0.0, false)
+
+	if _, err := NewTrainer(m, opt, MSE, 1, 10, 1).Fit(GetInputs(), GetLabels()[:10]); err == nil {
+		t.Errorf("Training with a different number of inputs and labels should have failed")
+	}
+	if _, err := NewTrainer(m, opt, MSE, 1, 0, 1).Fit(GetInputs(), GetLabels()); err == nil {
+		t.Errorf("Training with empty batches should have failed")
+	}
+	if _, err := NewTrainer(m, opt, MSE, 1, 10, 1).Fit([][]float64{{1.0, 2.0, 3.0}}, []float64{1.0}); err == nil {
+		t.Errorf("Training with inputs of the wrong width should have failed")
+	}
+
+	// a bad sample in a later batch: the model must not be trained at all
+	before := make([]float64, len(m.Params()))
+	for i, p := range m.Params() {
+		before[i] = p.GetData()
+	}
+	inputs := append(append([][]float64{}, GetInputs()[:20]...), []float64{1.0})
+	labels := GetLabels()[:21]
+	if _, err := NewTrainer(m, opt, MSE, 1, 10, 1).Fit(inputs, labels); err == nil {
+		t.Errorf("Training with a later input of the wrong width should have failed")
+	}
+	for i, p := range m.Params() {
+		if p.GetData() != before[i] {
+			t.Errorf("Param at index %d changed although training failed, got:%f, want:%f", i, p.GetData(), before[i])
+		}
+	}
+}
@@ -1,36 +1,49 @@
 package grad
 
 import (
+	"fmt"
 	"math/rand"
+	"time"
 )
 
-// generates a slice of random floats comprised between min and max
+// generates a slice of random floats comprised between min and max using rng
 // e.g if min=-1 and max=1 the formula below will generate floats
 // 	   from -1 + rand.Float64() * 2 ==> -1+0*2 and -1+1*2
-// rand.Float64() generates numbers between [0, 1)
-func randFloats(min, max float64, n int) []*Value {
+// rng.Float64() generates numbers between [0, 1)
+func randFloats(rng *rand.Rand, min, max float64, n int) []*Value {
 	res := make([]*Value, n)
 
 	for i := range res {
-		res[i] = NewValue(min + rand.Float64()*(max-min))
+		res[i] = NewValue(min + rng.Float64()*(max-min))
 	}
 
 	return res
 }
 
+// returns a new random number generator seeded with the current time in nanoseconds
+// used whenever a seed is not explicitly provided
+func timeRand() *rand.Rand {
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
 func MSE(predicted *Value, expected float64) *Value {
 	exp := NewValue(expected)
 
 	return predicted.Sub(exp).Pow(2)
 }
 
-func group(data [][2]float64, labels []float64, k int) ([][][2]float64, [][]float64) {
-	var dataGroups [][][2]float64
+// divides data and labels in k sequential groups
+// when the samples are not a multiple of k the first groups get one more sample each
+func group(data [][]float64, labels []float64, k int) ([][][]float64, [][]float64) {
+	var dataGroups [][][]float64
 	var labelGroups [][]float64
 
 	start := 0
-	size := len(data) / k
 	for i := 0; i < k; i++ {
+		size := len(data) / k
+		if i < len(data)%k {
+			size++
+		}
 		dataGroups = append(dataGroups, data[start:start+size])
 		labelGroups = append(labelGroups, labels[start:start+size])
 		start += size
@@ -39,26 +52,12 @@ func group(data [][2]float64, labels []float64, k int) ([][][2]float64, [][]floa
 	return dataGroups, labelGroups
 }
 
-// dynamic learning rate
+// dynamic learning rate (linear decay from 1 to 0.1 times the base one)
+// use it as a Scheduler with SchedulerFunc(Alpha)
 func Alpha(pass int, iterations int) float64 {
 	return 1.0 - 0.9*float64(pass)/float64(iterations)
 }
 
-// implementation of pop for data values
-// return selected element and new slice (without the selected element)
-func popValue(slice [][][2]float64, i int) ([][2]float64, [][][2]float64) {
-	v := slice[i]
-	rest := append(slice[:i], slice[i+1:]...)
-
-	return v, rest
-}
-
-// implementation of pop for label values
-// return selected element and new slice (without the selected element)
-func popLabel(slice [][]float64, i int) ([]float64, [][]float64) {
-	return slice[i], append(slice[:i], slice[i+1:]...)
-}
-
 func avgValue(slice []float64) float64 {
 	result := 0.0
 	for _, el := range slice {
@@ -85,11 +84,14 @@ func L2(input []*Value, lambda *Value) *Value {
 	return lambda.Mul(sum)
 }
 
-func map2Pred(input [][2]float64, f func([2]float64) []*Value) []*Value {
+func map2Pred(input [][]float64, f func([]float64) ([]*Value, error)) []*Value {
 	mapped := make([]*Value, len(input))
 
 	for idx, inp := range input {
-		y := f(inp)
+		y, err := f(inp)
+		if err != nil {
+			panic(fmt.Sprintf("Something went wrong with gograd.map2Pred: %v", err))
+		}
 		if len(y) == 1 {
 			mapped[idx] = y[0]
 		} else {
@@ -1,6 +1,7 @@
 package grad
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -8,7 +9,7 @@ func TestGroups(t *testing.T) {
 	numberOfGroups := 5
 
 	// dummy data and dummy labels
-	dummyDataset := [][2]float64{
+	dummyDataset := [][]float64{
 		{5.39412337e-01, 8.61363932e-01},
 		{-1.03234535e+00, 5.77661126e-02},
 		{-1.12251058e+00, 4.40911069e-01},
@@ -34,7 +35,7 @@ func TestGroups(t *testing.T) {
 	}
 
 	// check that each element in the data groups correspond to the relative one in the data control dataset
-	controlDataDataset := [][][2]float64{
+	controlDataDataset := [][][]float64{
 		{{5.39412337e-01, 8.61363932e-01}, {-1.03234535e+00, 5.77661126e-02}},
 		{{-1.12251058e+00, 4.40911069e-01}, {6.34512779e-01, -3.86770491e-01}},
 		{{4.74812014e-01, 7.05693581e-01}, {9.23972493e-01, 4.34679296e-01}},
@@ -43,7 +44,7 @@ func TestGroups(t *testing.T) {
 	}
 	for groupIdx := 0; groupIdx < len(groupedValues); groupIdx++ {
 		for elIdx := 0; elIdx < len(groupedValues[groupIdx]); elIdx++ {
-			if groupedValues[groupIdx][elIdx] != controlDataDataset[groupIdx][elIdx] {
+			if !reflect.DeepEqual(groupedValues[groupIdx][elIdx], controlDataDataset[groupIdx][elIdx]) {
 				t.Errorf("The group at index:%d is not the same as the relative one in the control dataset, got:%v, want:%v",
 					groupIdx,
 					groupedValues[groupIdx],
@@ -68,51 +69,37 @@ func TestGroups(t *testing.T) {
 	}
 }
 
-func TestPopGroupedDataset(t *testing.T) {
-	groupedDataDataset := [][][2]float64{
-		{{5.39412337e-01, 8.61363932e-01}, {-1.03234535e+00, 5.77661126e-02}},
-		{{-1.12251058e+00, 4.40911069e-01}, {6.34512779e-01, -3.86770491e-01}},
-		{{4.74812014e-01, 7.05693581e-01}, {9.23972493e-01, 4.34679296e-01}},
-		{{6.05938266e-01, -3.99049289e-01}, {3.38158252e-01, 1.00461575e+00}},
-		{{-9.65489273e-01, 1.44116250e-01}, {1.73508562e+00, -3.03348212e-01}},
-	}
-	controlDataDataset := [][][2]float64{
-		{{5.39412337e-01, 8.61363932e-01}, {-1.03234535e+00, 5.77661126e-02}},
-		{{-1.12251058e+00, 4.40911069e-01}, {6.34512779e-01, -3.86770491e-01}},
-		{{4.74812014e-01, 7.05693581e-01}, {9.23972493e-01, 4.34679296e-01}},
-		{{-9.65489273e-01, 1.44116250e-01}, {1.73508562e+00, -3.03348212e-01}},
+func TestGroupsRemainder(t *testing.T) {
+	data := GetInputs()[:11]
+	groupedValues, groupedLabels := group(data, GetLabels()[:11], 3)
+
+	// 11 samples in 3 groups, no sample is dropped
+	total := 0
+	for idx, want := range []int{4, 4, 3} {
+		if len(groupedValues[idx]) != want || len(groupedLabels[idx]) != want {
+			t.Errorf("The group at index:%d has the wrong size, got:%d, want:%d", idx, len(groupedValues[idx]), want)
+		}
+		total += len(groupedValues[idx])
 	}
-	controlValues := [][2]float64{
-		{6.05938266e-01, -3.99049289e-01}, {3.38158252e-01, 1.00461575e+00},
+	if total != len(data) {
+		t.Errorf("The groups do not contain every sample, got:%d, want:%d", total, len(data))
 	}
+}
 
-	values, modifiedDataset := popValue(groupedDataDataset, 3)
-
-	// check that the original dataset has now the right length
-	if len(modifiedDataset) != len(controlDataDataset) {
-		t.Errorf("The dataset from which a value was popped has a different length from the control one, got:%d, want:%d",
-			len(modifiedDataset),
-			len(controlDataDataset),
-		)
+func TestMap2PredAnyWidth(t *testing.T) {
+	// three features per sample
+	inputs := [][]float64{
+		{5.39412337e-01, 8.61363932e-01, 1.0},
+		{-1.03234535e+00, 5.77661126e-02, 0.0},
+		{-1.12251058e+00, 4.40911069e-01, -1.0},
 	}
+	m := NewModel(3, []int{4, 1})
 
-	// check the length of the extracted element
-	if len(values) != len(controlValues) {
-		t.Errorf("The popped element has a different length from the control one, got:%d, want:%d",
-			len(values),
-			len(controlValues),
+	preds := map2Pred(inputs, m.FeedForward)
+	if len(preds) != len(inputs) {
+		t.Errorf("The number of predictions is different from the number of inputs, got:%d, want:%d",
+			len(preds),
+			len(inputs),
 		)
 	}
-
-	// check the extracted element's values
-	for i := 0; i < len(values); i++ {
-		for j := 0; j < len(values[i]); j++ {
-			if values[i][j] != controlValues[i][j] {
-				t.Errorf("The extracted element's value is different from the control one, got:%v, want:%v",
-					values[i][j],
-					controlValues[i][j],
-				)
-			}
-		}
-	}
 }
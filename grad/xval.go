@@ -1,10 +1,13 @@
 package grad
 
 import (
+	"context"
 	"fmt"
 	"math"
-	"strconv"
+	"math/rand"
+	"runtime"
 	"sync"
+	"time"
 )
 
 type floatingRange struct {
@@ -37,180 +40,442 @@ func (ft *floatingRange) next() float64 {
 	return v
 }
 
+// a copy of the range restarting from its first value
+func (ft *floatingRange) clone() *floatingRange {
+	return NewFloatingRange(ft.start, ft.end, ft.step)
+}
+
 type XVal struct {
-	model      *Model
 	modelArch  []int
-	k          int
-	alpha      func(int, int) float64
+	alpha      Scheduler
 	lossFn     func(*Value, float64) *Value
-	values     [][][2]float64
-	labels     [][]float64
+	data       [][]float64
+	labels     []float64
+	splitter   Splitter
+	splits     []Split
 	hyperRange *floatingRange
-	cvScores   map[string][]float64
+	newOpt     func([]*Value) Optimizer
+	epochs     int
+	reg        func([]*Value, *Value) *Value
+	metric     Metric
+	logger     Logger
+	workers    int
+	rng        *rand.Rand
+	mu         sync.Mutex // guards rng
+}
+
+// Logger receives the progress messages of cross validation, e.g. a *log.Logger
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// training state owned by a single fold of cross validation
+// nothing in here is shared with the other folds running concurrently
+type foldState struct {
+	model *Model
+	opt   Optimizer
+	sched Scheduler
+	base  float64 // learning rate before scheduling
+}
+
+// a new model and a new optimizer and scheduler bound to it
+func (xv *XVal) newFold(seed int64) *foldState {
+	model := NewModel(xv.modelArch[0], xv.modelArch[1:], WithSeed(seed))
+	opt := xv.newOpt(model.Params())
+
+	return &foldState{
+		model: model,
+		opt:   opt,
+		sched: xv.alpha.Clone(),
+		base:  opt.LearningRate(),
+	}
+}
+
+// optional settings used when creating a new cross validation struct
+type XValOption func(*XVal)
+
+// WithXValSeed makes cross validation reproducible: the weights of every model
+// it trains are drawn from generators derived from seed
+// by default the generators are seeded with the current time
+func WithXValSeed(seed int64) XValOption {
+	return func(xv *XVal) {
+		xv.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithLogger makes cross validation report its progress to l
+// by default nothing is printed
+func WithLogger(l Logger) XValOption {
+	return func(xv *XVal) {
+		xv.logger = l
+	}
+}
+
+// WithOptimizer sets the function used to create the optimizer of each model trained during cross validation
+// by default plain gradient descent with a 0.0005 learning rate is used
+func WithOptimizer(newOpt func(params []*Value) Optimizer) XValOption {
+	return func(xv *XVal) {
+		xv.newOpt = newOpt
+	}
+}
+
+// WithEpochs sets how many times each model trained during cross validation goes through its training samples
+// by default 10
+func WithEpochs(n int) XValOption {
+	return func(xv *XVal) {
+		xv.epochs = n
+	}
+}
+
+// WithRegularizer sets the penalty added to the loss, computed from the parameters of the model
+// and the hyperparameter being searched (e.g. a custom L1 penalty)
+// by default L2 is used, the searched hyperparameter being its lambda
+// reg is traced once when each fold starts training, so it must depend on the params and lambda only
+func WithRegularizer(reg func(params []*Value, lambda *Value) *Value) XValOption {
+	return func(xv *XVal) {
+		xv.reg = reg
+	}
+}
+
+// WithMetric sets how the models are scored on their holdout samples, and so which hyperparameter is the best
+// by default MetricAccuracy is used
+func WithMetric(m Metric) XValOption {
+	return func(xv *XVal) {
+		xv.metric = m
+	}
+}
+
+// WithWorkers sets how many folds can be trained at the same time
+// by default as many as runtime.GOMAXPROCS(0)
+func WithWorkers(n int) XValOption {
+	return func(xv *XVal) {
+		xv.workers = n
+	}
+}
+
+// WithSplitter sets how the samples are divided between training and holdout in each fold
+// by default they are divided in k sequential folds, as with NewKFold(k)
+func WithSplitter(s Splitter) XValOption {
+	return func(xv *XVal) {
+		xv.splitter = s
+	}
 }
 
 func NewXVal(
-	data [][2]float64,
+	data [][]float64,
 	labels []float64,
 	arch []int,
 	fr *floatingRange,
-	alpha func(int, int) float64,
+	alpha Scheduler,
 	lossFunc func(*Value, float64) *Value,
 	k int,
+	opts ...XValOption,
 ) *XVal {
-	groupedValues, groupedLabels := group(data, labels, k)
+	// checked here, as the folds run on other goroutines where a panic cannot be recovered
+	if len(data) != len(labels) {
+		panic(fmt.Sprintf("gograd: %d samples but %d labels", len(data), len(labels)))
+	}
+	// every sample must have as many features as the inputs of the network
+	for idx, d := range data {
+		if len(d) != arch[0] {
+			panic(fmt.Sprintf("gograd: sample at index %d has %d features, network expects %d", idx, len(d), arch[0]))
+		}
+	}
+
+	// without a scheduler the learning rate of the optimizer is used as it is
+	if alpha == nil {
+		alpha = SchedulerFunc(func(step int, total int) float64 { return 1.0 })
+	}
 
 	xv := XVal{
-		model:      nil, // initialized later (when testing against hyperparameters)
 		modelArch:  arch,
-		k:          k,
 		alpha:      alpha,
 		lossFn:     lossFunc,
-		values:     groupedValues,
-		labels:     groupedLabels,
+		data:       data,
+		labels:     labels,
 		hyperRange: fr,
-		cvScores:   make(map[string][]float64),
+		newOpt: func(params []*Value) Optimizer {
+			return NewSGD(params, 0.0005, 0.0, false)
+		},
+		epochs: 10,
+		reg:    L2,
+		metric: MetricAccuracy,
+	}
+	for _, opt := range opts {
+		opt(&xv)
+	}
+	if xv.rng == nil {
+		xv.rng = timeRand()
+	}
+	if xv.workers < 1 {
+		xv.workers = runtime.GOMAXPROCS(0)
+	}
+	if xv.splitter == nil {
+		xv.splitter = NewKFold(k)
+	}
+	// the same splits are used for every hyperparameter so that their scores are comparable
+	xv.splits = xv.splitter.Splits(labels)
+	for idx, split := range xv.splits {
+		if len(split.Holdout) == 0 || len(split.Train) == 0 {
+			panic(fmt.Sprintf("gograd: split %d has %d training and %d holdout samples", idx, len(split.Train), len(split.Holdout)))
+		}
 	}
 
 	return &xv
 }
 
-func (xv *XVal) SearchBestHyperpar() *Value {
-	var hyperpars []float64
+// SearchBestHyperpar evaluates every L2 lambda in the range with cross validation
+// and reports the scores of each one, the best is the one with the best mean score
+// (the highest or the lowest depending on the metric, the smallest lambda wins ties)
+// Best is left unset when the range is empty, SearchBestHyperparContext also reports that as an error
+func (xv *XVal) SearchBestHyperpar() CVResult {
+	res, _ := xv.SearchBestHyperparContext(context.Background())
+
+	return res
+}
+
+// a single fold of a single hyperparameter, the unit of work of the worker pool
+type foldJob struct {
+	hyper  int // index of the hyperparameter
+	fold   int // index of the split
+	lambda float64
+	seed   int64
+}
+
+type foldResult struct {
+	foldJob
+	score float64
+	start time.Time
+	end   time.Time
+	err   error
+}
 
-	fmt.Printf(
+// SearchBestHyperparContext is SearchBestHyperpar running the folds of every hyperparameter
+// on a pool of workers (see WithWorkers) until they are done or ctx is cancelled
+// in the latter case the report contains only the hyperparameters whose folds all completed
+// and the error of ctx is returned with it (Best is left unset when none of them completed)
+// the same happens when a fold fails, with the error of the first one
+// an empty range is an error too, since there is no hyperparameter to choose
+func (xv *XVal) SearchBestHyperparContext(ctx context.Context) (CVResult, error) {
+	res := CVResult{Metric: xv.metric.Name}
+	start := time.Now()
+
+	xv.logf(
 		"==> Using Cross Validation to look for the best L2 lambda hyperparameter in values ranging from %.4f to %.4f\n",
 		xv.hyperRange.start,
 		xv.hyperRange.end,
 	)
 
+	// every search starts from the beginning of the range, which is never consumed
 	// check against a control value to know if there are more steps
-	h := xv.hyperRange.next()
-	for h != math.MaxFloat64 {
-		var scores []float64
-
-		// here golang's concurrency is used to speed up cross validation (with goroutines and channels)
-		var xvalWg sync.WaitGroup            // goroutines wait group
-		scoresCh := make(chan float64, xv.k) // holdouts scores buffered channel
-
-		// loop for k times changing the holdout each time
-		for ki := 0; ki < xv.k; ki++ {
-			// increment workers counter
-			xvalWg.Add(1)
-
-			// make a copy of the data and relative labels to pass to each goroutine
-			valuesCopy := make([][][2]float64, len(xv.values))
-			copy(valuesCopy, xv.values)
-			labelsCopy := make([][]float64, len(xv.labels))
-			copy(labelsCopy, xv.labels)
-
-			// goroutine to compute cross validation on this iteration group (out of xv.k for each hypervalue in range)
-			go func(data [][][2]float64, labels [][]float64, idx int, lambda float64, ch chan<- float64) {
-				defer xvalWg.Done()
-
-				// prepping
-				holdoutValues, trainingValues := popValue(data, idx)
-				holdoutLabels, trainingLabels := popLabel(labels, idx)
-
-				// small training session (each time with a different model)
-				xv.miniTrain(trainingValues, trainingLabels, NewValue(lambda))
-				// holdout testing on previous training session to compute accuracy metric w.r.t. current hyperpar
-				xv.holdout(holdoutValues, holdoutLabels, ch)
-			}(valuesCopy, labelsCopy, ki, h, scoresCh)
+	var hypers []float64
+	hr := xv.hyperRange.clone()
+	for h := hr.next(); h != math.MaxFloat64; h = hr.next() {
+		hypers = append(hypers, h)
+	}
+	if len(hypers) == 0 {
+		res.Duration = time.Since(start)
+		return res, fmt.Errorf(
+			"gograd: no hyperparameter to evaluate in the range from %f to %f",
+			xv.hyperRange.start,
+			xv.hyperRange.end,
+		)
+	}
+
+	jobs := make(chan foldJob)
+	results := make(chan foldResult)
+
+	// here golang's concurrency is used to speed up cross validation (with goroutines and channels)
+	var workersWg sync.WaitGroup
+	for w := 0; w < xv.workers; w++ {
+		workersWg.Add(1)
+		go func() {
+			defer workersWg.Done()
+			for job := range jobs {
+				results <- xv.runFold(ctx, job)
+			}
+		}()
+	}
+
+	// jobs (and the seeds of their models) are generated in a fixed order to keep runs reproducible
+	go func() {
+		defer close(jobs)
+		for hi, h := range hypers {
+			for ki := range xv.splits {
+				job := foldJob{hyper: hi, fold: ki, lambda: h, seed: xv.nextSeed()}
+				select {
+				case jobs <- job:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	go func() {
+		workersWg.Wait()
+		close(results)
+	}()
+
+	// collect the scores, a hyperparameter is complete when none of its folds is left
+	scores := make([][]float64, len(hypers))
+	left := make([]int, len(hypers))
+	first := make([]time.Time, len(hypers))
+	last := make([]time.Time, len(hypers))
+	for hi := range hypers {
+		scores[hi] = make([]float64, len(xv.splits))
+		left[hi] = len(xv.splits)
+	}
+	var foldErr error
+	for r := range results {
+		if r.err != nil {
+			// the first error of a fold that was not just cancelled is reported
+			if foldErr == nil && r.err != ctx.Err() {
+				foldErr = r.err
+			}
+			continue
 		}
 
-		// wait until all workers are done then close channel
-		xvalWg.Wait()
-		close(scoresCh)
+		scores[r.hyper][r.fold] = r.score
+		left[r.hyper]--
+		if first[r.hyper].IsZero() || r.start.Before(first[r.hyper]) {
+			first[r.hyper] = r.start
+		}
+		if r.end.After(last[r.hyper]) {
+			last[r.hyper] = r.end
+		}
 
-		// read scores from each of the xv.k cross validation workers of this hyperparameter
-		for acc := range scoresCh {
-			scores = append(scores, acc)
+		if left[r.hyper] == 0 {
+			mean, std := avgValue(scores[r.hyper]), stdDev(scores[r.hyper])
+			xv.logf("hyperpar=%.4f, %s=%.4f (std dev %.4f)\n", r.lambda, xv.metric.Name, mean, std)
 		}
+	}
 
-		// average the xv.k holdout scores, creating a mean for this hyperparameter
-		avgScore := avgValue(scores)
-		fmt.Printf("hyperpar=%.4f, accuracy=%.0f%%\n", h, avgScore*100)
-
-		// add score to map if not present
-		if _, ok := xv.cvScores[fmt.Sprintf("%.4f", avgScore)]; !ok {
-			// create slice beacuse not present
-			xv.cvScores[fmt.Sprintf("%.4f", avgScore)] = []float64{h}
-		} else {
-			// append element to existing slice
-			xv.cvScores[fmt.Sprintf("%.4f", avgScore)] = append(xv.cvScores[fmt.Sprintf("%.4f", avgScore)], h)
+	// summarize the holdout scores of each complete hyperparameter, in the order of the range
+	for hi, h := range hypers {
+		if left[hi] > 0 {
+			continue
 		}
 
-		// get hyperparameter candidate value for next iteration
-		h = xv.hyperRange.next()
+		hs := newHyperparScore(h, scores[hi], last[hi].Sub(first[hi]))
+		res.Scores = append(res.Scores, hs)
+
+		// strictly better, so that ties keep the smallest hyperparameter
+		if len(res.Scores) == 1 || xv.metric.better(hs.Mean, res.Best.Mean) {
+			res.Best = hs
+		}
 	}
 
-	// get hyperpar associated with highest accuracy rate
-	maxKey := math.Inf(-1)
-	for k, v := range xv.cvScores {
-		if scoreK, err := strconv.ParseFloat(k, 64); err == nil {
-			if scoreK > maxKey {
-				maxKey = scoreK
-				hyperpars = v
-			}
+	res.Duration = time.Since(start)
+
+	if len(res.Scores) < len(hypers) {
+		if err := ctx.Err(); err != nil {
+			return res, err
 		}
+		return res, foldErr
+	}
+
+	return res, nil
+}
+
+// trains a new model on the training samples of the split of job and scores it on the holdout ones
+func (xv *XVal) runFold(ctx context.Context, job foldJob) foldResult {
+	r := foldResult{foldJob: job, start: time.Now()}
+	if r.err = ctx.Err(); r.err != nil {
+		return r
+	}
+
+	// each fold gets its own model
+	fold := xv.newFold(job.seed)
+	split := xv.splits[job.fold]
+
+	if len(split.Holdout) == 0 {
+		r.err = fmt.Errorf("gograd: split %d has no holdout samples", job.fold)
+		return r
+	}
+
+	// prepping, the training samples are divided in groups as large as the holdout
+	holdoutValues, holdoutLabels := subset(xv.data, xv.labels, split.Holdout)
+	trainingValues, trainingLabels := subset(xv.data, xv.labels, split.Train)
+	groups := len(split.Train) / len(split.Holdout)
+	if groups < 1 {
+		groups = 1
+	}
+	groupedValues, groupedLabels := group(trainingValues, trainingLabels, groups)
+
+	// small training session (each time with a different model)
+	xv.miniTrain(ctx, fold, groupedValues, groupedLabels, NewValue(job.lambda))
+	if r.err = ctx.Err(); r.err != nil {
+		return r
+	}
+
+	// holdout testing on previous training session to compute the metric w.r.t. current hyperpar
+	r.score = xv.holdout(fold.model, holdoutValues, holdoutLabels)
+	r.end = time.Now()
+
+	return r
+}
+
+func (xv *XVal) logf(format string, v ...interface{}) {
+	if xv.logger != nil {
+		xv.logger.Printf(format, v...)
 	}
+}
+
+// the following methods are safe to be called from concurrent goroutines
 
-	// get first element of the slice as they all have the same score
-	return NewValue(hyperpars[0])
+func (xv *XVal) nextSeed() int64 {
+	xv.mu.Lock()
+	defer xv.mu.Unlock()
+
+	return xv.rng.Int63()
 }
 
-func (xv *XVal) miniTrain(inputs [][][2]float64, expectations [][]float64, hyperpar *Value) {
-	// each time a mini train occurs a new model is created
-	xv.model = NewModel(xv.modelArch[0], xv.modelArch[1:])
+// trains the model of fold, which is not shared with any other goroutine
+// each epoch takes a step for each group of inputs, training stops early when ctx is cancelled
+func (xv *XVal) miniTrain(ctx context.Context, fold *foldState, inputs [][][]float64, expectations [][]float64, hyperpar *Value) {
+	model, opt, sched, base := fold.model, fold.opt, fold.sched, fold.base
+	steps := len(inputs) * xv.epochs
 
 	// check inputs are the same length of expectations
 	if len(inputs) != len(expectations) {
 		panic("Something bad occurred during a mini training session of cross validation. Inputs and Labels are not the same length")
 	}
 
-	// train the model on each input value for 10 times each
-	for idx, inp := range inputs {
-		// for each slice of input in inputs train the model 10 times
-		for pass := 0; pass < 10; pass++ {
-			// prepping
-			xv.model.ZeroGrad()
-
-			// prediction and loss
-			preds := map2Pred(inp, xv.model.FeedForward)
-			losses := map2Losses(preds, expectations[idx], MSE)
-			loss := NewValue(0.0)
-			for _, el := range losses {
-				loss.Add(el)
+	// the model and its regularization are traced once and executed again at every step
+	compiled := compileModel(model, func(ps []*Value) *Value {
+		return xv.reg(ps, hyperpar)
+	})
+
+	for epoch := 0; epoch < xv.epochs; epoch++ {
+		for idx, inp := range inputs {
+			if ctx.Err() != nil {
+				return
 			}
-			loss.Div(len(losses))
-
-			// regularize loss with L2
-			reg := L2(xv.model.Params(), hyperpar)
-			totLoss := loss.Add(reg)
-
-			// backward pass
-			totLoss.BackwardPass()
-			for _, el := range xv.model.Params() {
-				// TODO: change this learning rate with the dynamic one
-				// el.data -= 0.0005 * el.grad
-				el.Update(0.0005)
+
+			// prepping
+			opt.ZeroGrad()
+			applySchedule(opt, sched, base, epoch*len(inputs)+idx, steps)
+
+			// backward pass of the mean loss of the group, one sample at a time
+			loss := 0.0
+			for i, x := range inp {
+				_, l, err := compiled.sampleGrad(x, expectations[idx][i], xv.lossFn, 1/float64(len(inp)))
+				if err != nil {
+					panic(fmt.Sprintf("gograd: cross validation training failed: %v", err))
+				}
+				loss += l / float64(len(inp))
 			}
-		}
-	}
-}
 
-func (xv *XVal) holdout(inputs [][2]float64, expectations []float64, scoresCh chan<- float64) {
-	preds := map2Pred(inputs, xv.model.FeedForward)
+			// regularize loss
+			totLoss := loss + compiled.regGrad()
 
-	// compute accuracy (i.e. the value to be returned)
-	directionsSum := 0.0
-	for idx := range preds {
-		if (preds[idx].GetData() > 0.0) == (expectations[idx] > 0.0) {
-			directionsSum += 1.0
+			opt.Step()
+			sched.Observe(totLoss)
 		}
 	}
-	acc := directionsSum / float64(len(preds))
+}
 
-	scoresCh <- acc
+func (xv *XVal) holdout(model *Model, inputs [][]float64, expectations []float64) float64 {
+	return xv.metric.Score(outputs(model, inputs), expectations)
 }
@@ -0,0 +1,382 @@
+package grad
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// small cross validation setup used by the tests below
+func newTestXVal(opts ...XValOption) *XVal {
+	return NewXVal(
+		GetInputs()[:40],
+		GetLabels()[:40],
+		[]int{2, 4, 1},
+		NewFloatingRange(0.0, 0.001, 0.0005),
+		SchedulerFunc(Alpha),
+		MSE,
+		4,
+		opts...,
+	)
+}
+
+func TestXValSeed(t *testing.T) {
+	a := newTestXVal(WithXValSeed(3))
+	b := newTestXVal(WithXValSeed(3))
+
+	resA := a.SearchBestHyperpar()
+	resB := b.SearchBestHyperpar()
+
+	if resA.Best.Hyperpar != resB.Best.Hyperpar {
+		t.Errorf("Cross validations with the same seed chose different hyperparameters, got:%f, want:%f", resB.Best.Hyperpar, resA.Best.Hyperpar)
+	}
+	if !sameScores(resA, resB) {
+		t.Errorf("Cross validations with the same seed produced different scores, got:%v, want:%v", resB.Scores, resA.Scores)
+	}
+}
+
+func TestXValSplitter(t *testing.T) {
+	res := newTestXVal(WithXValSeed(1), WithSplitter(NewRepeatedKFold(4, 2, 1))).SearchBestHyperpar()
+
+	for _, hs := range res.Scores {
+		if len(hs.Folds) != 8 {
+			t.Errorf("Hyperparameter %f has the wrong number of fold scores, got:%d, want:%d", hs.Hyperpar, len(hs.Folds), 8)
+		}
+	}
+
+	res = newTestXVal(WithXValSeed(1), WithSplitter(NewHoldoutSplit(0.25, 1))).SearchBestHyperpar()
+	for _, hs := range res.Scores {
+		if len(hs.Folds) != 1 {
+			t.Errorf("Hyperparameter %f has the wrong number of fold scores, got:%d, want:%d", hs.Hyperpar, len(hs.Folds), 1)
+		}
+	}
+}
+
+func TestXValFoldsOwnModels(t *testing.T) {
+	xv := newTestXVal(WithXValSeed(1))
+	a := xv.newFold(xv.nextSeed())
+	b := xv.newFold(xv.nextSeed())
+
+	if a.model == b.model || a.opt == b.opt {
+		t.Fatalf("Two folds share the same model or optimizer")
+	}
+	shared := map[*Value]bool{}
+	for _, p := range a.model.Params() {
+		shared[p] = true
+	}
+	for _, p := range b.model.Params() {
+		if shared[p] {
+			t.Errorf("Two folds share the same parameter")
+		}
+	}
+}
+
+func TestXValConcurrent(t *testing.T) {
+	// several cross validations (each one with concurrent folds) running at the same time
+	// must not interfere with each other, run with -race to detect unsynchronized accesses
+	const runs = 4
+	xvs := make([]*XVal, runs)
+	results := make([]CVResult, runs)
+
+	var wg sync.WaitGroup
+	for i := range xvs {
+		xvs[i] = newTestXVal(WithXValSeed(5))
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = xvs[i].SearchBestHyperpar()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 1; i < runs; i++ {
+		if results[i].Best.Hyperpar != results[0].Best.Hyperpar {
+			t.Errorf("Concurrent cross validations with the same seed chose different hyperparameters, got:%f, want:%f", results[i].Best.Hyperpar, results[0].Best.Hyperpar)
+		}
+		if !sameScores(results[i], results[0]) {
+			t.Errorf("Concurrent cross validations with the same seed produced different scores, got:%v, want:%v", results[i].Scores, results[0].Scores)
+		}
+	}
+}
+
+func TestXValWorkers(t *testing.T) {
+	one := newTestXVal(WithXValSeed(6), WithWorkers(1)).SearchBestHyperpar()
+	many := newTestXVal(WithXValSeed(6), WithWorkers(16)).SearchBestHyperpar()
+
+	if !sameScores(one, many) {
+		t.Errorf("The number of workers changed the scores, got:%v, want:%v", many.Scores, one.Scores)
+	}
+}
+
+func TestXValContext(t *testing.T) {
+	// already cancelled: nothing is evaluated
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := newTestXVal(WithXValSeed(7)).SearchBestHyperparContext(ctx)
+	if err != context.Canceled {
+		t.Errorf("A cancelled search returned the wrong error, got:%v, want:%v", err, context.Canceled)
+	}
+	if len(res.Scores) != 0 {
+		t.Errorf("A cancelled search reported %d hyperparameters, want:0", len(res.Scores))
+	}
+
+	// cancelled once the first hyperparameter is complete: a single worker keeps the folds in order
+	// so only the first hyperparameter can be reported, with the same scores of a full run
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	logger := testLogger(func(s string) {
+		if strings.HasPrefix(s, "hyperpar=") {
+			cancel()
+		}
+	})
+	res, err = newTestXVal(WithXValSeed(7), WithWorkers(1)).SearchBestHyperparContext(ctx)
+	if err != nil {
+		t.Fatalf("A search that was not cancelled failed with error: %v", err)
+	}
+	partial, err := newTestXVal(WithXValSeed(7), WithWorkers(1), WithLogger(logger)).SearchBestHyperparContext(ctx)
+	if err != context.Canceled {
+		t.Errorf("A cancelled search returned the wrong error, got:%v, want:%v", err, context.Canceled)
+	}
+	if len(partial.Scores) != 1 {
+		t.Fatalf("A cancelled search reported the wrong number of hyperparameters, got:%d, want:%d", len(partial.Scores), 1)
+	}
+	if !reflect.DeepEqual(partial.Scores[0].Folds, res.Scores[0].Folds) || partial.Best.Hyperpar != partial.Scores[0].Hyperpar {
+		t.Errorf("The partial report does not match the full one, got:%v, want:%v", partial.Scores[0], res.Scores[0])
+	}
+
+	// deadline in the past
+	ctx, cancel = context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	if _, err := newTestXVal(WithXValSeed(7)).SearchBestHyperparContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("A search past its deadline returned the wrong error, got:%v, want:%v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestXValRange(t *testing.T) {
+	// the range is not consumed, so a second search evaluates the same hyperparameters
+	xv := newTestXVal(WithXValSeed(4))
+	first, err := xv.SearchBestHyperparContext(context.Background())
+	if err != nil {
+		t.Fatalf("The first search failed with error: %v", err)
+	}
+	second, err := xv.SearchBestHyperparContext(context.Background())
+	if err != nil {
+		t.Fatalf("The second search failed with error: %v", err)
+	}
+	if len(second.Scores) != len(first.Scores) || len(second.Scores) != 3 {
+		t.Fatalf("The second search evaluated the wrong number of hyperparameters, got:%d, want:%d", len(second.Scores), 3)
+	}
+	for idx := range first.Scores {
+		if second.Scores[idx].Hyperpar != first.Scores[idx].Hyperpar {
+			t.Errorf("The second search evaluated the wrong hyperparameter at index %d, got:%f, want:%f", idx, second.Scores[idx].Hyperpar, first.Scores[idx].Hyperpar)
+		}
+	}
+
+	// an empty range has no best hyperparameter
+	empty := NewXVal(
+		GetInputs()[:40],
+		GetLabels()[:40],
+		[]int{2, 4, 1},
+		NewFloatingRange(0.01, 0.0, 0.0005),
+		SchedulerFunc(Alpha),
+		MSE,
+		4,
+	)
+	res, err := empty.SearchBestHyperparContext(context.Background())
+	if err == nil {
+		t.Errorf("A search over an empty range did not fail")
+	}
+	if len(res.Scores) != 0 || !reflect.DeepEqual(res.Best, HyperparScore{}) {
+		t.Errorf("A search over an empty range reported a hyperparameter, got:%v", res.Best)
+	}
+}
+
+func TestXValNilScheduler(t *testing.T) {
+	xv := NewXVal(
+		GetInputs()[:40],
+		GetLabels()[:40],
+		[]int{2, 4, 1},
+		NewFloatingRange(0.0, 0.0005, 0.0005),
+		nil,
+		MSE,
+		4,
+		WithXValSeed(5),
+	)
+
+	res, err := xv.SearchBestHyperparContext(context.Background())
+	if err != nil {
+		t.Fatalf("A search without a scheduler failed with error: %v", err)
+	}
+	if len(res.Scores) != 2 {
+		t.Errorf("The report has the wrong number of hyperparameters, got:%d, want:%d", len(res.Scores), 2)
+	}
+}
+
+// a splitter returning fixed splits
+type fixedSplitter []Split
+
+func (fs fixedSplitter) Splits(labels []float64) []Split {
+	return fs
+}
+
+func TestXValPanics(t *testing.T) {
+	newXVal := func(data [][]float64, labels []float64, opts ...XValOption) {
+		NewXVal(data, labels, []int{2, 4, 1}, NewFloatingRange(0.0, 0.001, 0.0005), nil, MSE, 4, opts...)
+	}
+
+	for _, tc := range []struct {
+		name string
+		f    func()
+	}{
+		{"fewer samples than labels", func() { newXVal(GetInputs()[:20], GetLabels()[:40]) }},
+		{"fewer labels than samples", func() { newXVal(GetInputs()[:40], GetLabels()[:20]) }},
+		{"a single sample", func() { newXVal(GetInputs()[:1], GetLabels()[:1], WithSplitter(NewHoldoutSplit(0.25, 1))) }},
+		{"an empty holdout", func() {
+			newXVal(GetInputs()[:4], GetLabels()[:4], WithSplitter(fixedSplitter{{Train: []int{0, 1, 2, 3}}}))
+		}},
+		{"nested with fewer samples than labels", func() {
+			NestedXVal(GetInputs()[:20], GetLabels()[:40], []int{2, 4, 1}, NewFloatingRange(0.0, 0.001, 0.0005), nil, MSE, NewKFold(2), 2)
+		}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s should have panicked", tc.name)
+				}
+			}()
+			tc.f()
+		}()
+	}
+}
+
+func TestXValMetric(t *testing.T) {
+	res := newTestXVal(WithXValSeed(8), WithMetric(MetricMSE)).SearchBestHyperpar()
+
+	if res.Metric != "mse" {
+		t.Errorf("The report has the wrong metric, got:%s, want:%s", res.Metric, "mse")
+	}
+	for idx, s := range res.Scores {
+		if s.Mean < res.Best.Mean || (s.Mean == res.Best.Mean && s.Hyperpar < res.Best.Hyperpar) {
+			t.Errorf("Hyperparameter at index %d should have been chosen over %f", idx, res.Best.Hyperpar)
+		}
+	}
+}
+
+func TestXValTraining(t *testing.T) {
+	// the hyperparameter is passed to the regularizer of every training step
+	var mu sync.Mutex
+	seen := map[float64]bool{}
+	reg := func(ps []*Value, lambda *Value) *Value {
+		mu.Lock()
+		seen[lambda.GetData()] = true
+		mu.Unlock()
+		return L2(ps, lambda)
+	}
+	newAdam := func(ps []*Value) Optimizer { return NewAdam(ps, 0.01, 0.9, 0.999, 1e-8) }
+
+	short := newTestXVal(WithXValSeed(2), WithOptimizer(newAdam), WithRegularizer(reg), WithEpochs(1), WithMetric(MetricMSE)).SearchBestHyperpar()
+	long := newTestXVal(WithXValSeed(2), WithOptimizer(newAdam), WithRegularizer(reg), WithEpochs(30), WithMetric(MetricMSE)).SearchBestHyperpar()
+
+	if len(seen) != 3 {
+		t.Errorf("The regularizer received the wrong number of distinct hyperparameters, got:%d, want:%d", len(seen), 3)
+	}
+	// training must minimize the loss of the data, not just the penalty
+	if long.Best.Mean >= short.Best.Mean {
+		t.Errorf("Training for more epochs did not reduce the holdout error, got:%f, with one epoch:%f", long.Best.Mean, short.Best.Mean)
+	}
+}
+
+// compares two reports ignoring their timings
+func sameScores(a CVResult, b CVResult) bool {
+	if len(a.Scores) != len(b.Scores) {
+		return false
+	}
+	for idx := range a.Scores {
+		if a.Scores[idx].Hyperpar != b.Scores[idx].Hyperpar || !reflect.DeepEqual(a.Scores[idx].Folds, b.Scores[idx].Folds) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestXValResult(t *testing.T) {
+	var logged []string
+	res := newTestXVal(WithXValSeed(9), WithLogger(testLogger(func(s string) { logged = append(logged, s) }))).SearchBestHyperpar()
+
+	// 0.0000, 0.0005 and 0.0010
+	if len(res.Scores) != 3 {
+		t.Fatalf("The report has the wrong number of hyperparameters, got:%d, want:%d", len(res.Scores), 3)
+	}
+	if len(logged) != 4 {
+		t.Errorf("The logger received the wrong number of messages, got:%d, want:%d", len(logged), 4)
+	}
+
+	for idx, s := range res.Scores {
+		if len(s.Folds) != 4 {
+			t.Errorf("Hyperparameter %f has the wrong number of fold scores, got:%d, want:%d", s.Hyperpar, len(s.Folds), 4)
+		}
+		if s.Mean != avgValue(s.Folds) || s.StdDev != stdDev(s.Folds) {
+			t.Errorf("Hyperparameter %f summary does not match its fold scores", s.Hyperpar)
+		}
+		if s.Mean > res.Best.Mean || (s.Mean == res.Best.Mean && s.Hyperpar < res.Best.Hyperpar) {
+			t.Errorf("Hyperparameter at index %d should have been chosen over %f", idx, res.Best.Hyperpar)
+		}
+	}
+}
+
+func TestStdDev(t *testing.T) {
+	if got := stdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9}); math.Abs(got-2.138089935) > 1e-9 {
+		t.Errorf("Standard deviation was incorrect, got:%0.9f, want:%0.9f", got, 2.138089935)
+	}
+	if got := stdDev([]float64{1}); got != 0.0 {
+		t.Errorf("Standard deviation of a single value was incorrect, got:%f, want:%f", got, 0.0)
+	}
+}
+
+func TestCVResultEncoders(t *testing.T) {
+	res := CVResult{
+		Scores: []HyperparScore{
+			newHyperparScore(0.0, []float64{0.5, 0.75}, 2*time.Second),
+			newHyperparScore(0.001, []float64{1.0, 0.5}, time.Second),
+		},
+	}
+	res.Best = res.Scores[1]
+
+	var buf bytes.Buffer
+	if err := res.WriteCSV(&buf); err != nil {
+		t.Fatalf("Writing the CSV report failed with error: %v", err)
+	}
+	want := "hyperpar,mean,std_dev,training_time_s,best,fold_1,fold_2\n" +
+		"0,0.625,0.1767766952966369,2,false,0.5,0.75\n" +
+		"0.001,0.75,0.3535533905932738,1,true,1,0.5\n"
+	if buf.String() != want {
+		t.Errorf("The CSV report was incorrect, got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := res.WriteJSON(&buf); err != nil {
+		t.Fatalf("Writing the JSON report failed with error: %v", err)
+	}
+	var decoded CVResult
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Decoding the JSON report failed with error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, res) {
+		t.Errorf("The JSON report does not round trip, got:%v, want:%v", decoded, res)
+	}
+}
+
+// adapts a function to the Logger interface
+type testLogger func(string)
+
+func (l testLogger) Printf(format string, v ...interface{}) {
+	l(fmt.Sprintf(format, v...))
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"math/rand"
+	"os"
 	"time"
 
 	"github.com/dsprn/gograd/grad"
@@ -10,11 +13,17 @@ import (
 
 func main() {
 	// setting hyperparameters
-	alpha := 0.0005
+	alpha := 0.01
+	epochs := 50
+	batchSize := 10
 	arch := []int{16, 16, 1} // check this in the rust version
 
+	// seed used for every random choice, print it to be able to reproduce a run
+	seed := time.Now().UnixNano()
+	fmt.Printf("==> Using seed=%d\n", seed)
+
 	// creating model
-	m := grad.NewModel(2, arch)
+	m := grad.NewModel(2, arch, grad.WithSeed(seed))
 
 	// creating cross validation struct used to get best L2 lambda hyperparameter
 	xv := grad.NewXVal(
@@ -22,60 +31,52 @@ func main() {
 		grad.GetLabels(),
 		append([]int{2}, arch...), // prepend number of inputs to network architecture
 		grad.NewFloatingRange(0.0, 0.01, 0.0005),
-		grad.Alpha,
+		grad.SchedulerFunc(grad.Alpha),
 		grad.MSE,
 		10,
+		grad.WithXValSeed(seed),
+		grad.WithLogger(log.New(os.Stdout, "", 0)),
 	)
-	l2Lambda := xv.SearchBestHyperpar()
+	cvResult, err := xv.SearchBestHyperparContext(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	l2Lambda := grad.NewValue(cvResult.Best.Hyperpar)
 	fmt.Printf("==> L2 lambda value=%.4f\n", l2Lambda.GetData())
 
-	// seed and random int generator
-	s1 := rand.NewSource(time.Now().UnixNano())
-	r1 := rand.New(s1)
+	// random int generator
+	r1 := rand.New(rand.NewSource(seed))
+
+	// any other grad.Optimizer (e.g. grad.NewAdam) and grad.Scheduler can be used here
+	opt := grad.NewSGD(m.Params(), alpha, 0.9, false)
+	trainer := grad.NewTrainer(m, opt, grad.MSE, epochs, batchSize, seed)
+	trainer.SetScheduler(grad.SchedulerFunc(grad.Alpha))
+	trainer.SetRegularizer(func(ps []*grad.Value) *grad.Value {
+		return grad.L2(ps, l2Lambda)
+	})
+
+	// training on the whole dataset
+	fmt.Println("\n==> Start training the model...")
+	history, err := trainer.Fit(grad.GetInputs(), grad.GetLabels())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, h := range history {
+		fmt.Printf("epoch=%d, loss=%f, accuracy=%.0f%%\n", h.Epoch, h.Loss, h.Accuracy*100)
+	}
 
-	// choosing random data and label
+	// choosing random data and label to check the trained model
 	fmt.Println("\n==> Choosing inputs and relative label from a preloaded dataset...")
-	dataIndex := r1.Intn(100)
+	dataIndex := r1.Intn(len(grad.GetInputs()))
 	fmt.Printf("==> Getting inputs at index %d and relative label\n", dataIndex)
 	inputs := grad.GetInputs()[dataIndex]
 	label := grad.GetLabels()[dataIndex]
 	fmt.Printf("==> Input values=%v\n", inputs)
-	fmt.Printf("==> Expected value=%f\n", label)
-
-	// main loop
-	fmt.Println("\n==> Start training the model...")
-	for round := 1; round < 100; round++ {
-		// prepping for this round
-		m.ZeroGrad()
-
-		// forward pass
-		pred := m.FeedForward(inputs)
-		loss := grad.MSE(pred[0], label)
-
-		// L2 regularization
-		reg := grad.L2(m.Params(), l2Lambda)
-		totLoss := loss.Add(reg)
-
-		// backward pass
-		totLoss.BackwardPass()
-		for _, el := range m.Params() {
-			el.Update(alpha)
-		}
-
-		fmt.Printf(
-			"pass=%d, predicted=%f, expected=%.1f, loss=%f, reg=%f, tot_loss=%f\n",
-			round,
-			pred[0].GetData(),
-			label,
-			loss.GetData(),
-			reg.GetData(),
-			totLoss.GetData(),
-		)
 
-		// early exit (when results are good enough)
-		if loss.GetData() < 0.0001 {
-			break
-		}
+	pred, err := m.FeedForward(inputs)
+	if err != nil {
+		log.Fatal(err)
 	}
+	fmt.Printf("==> Predicted value=%f, expected value=%.1f\n", pred[0].GetData(), label)
 	fmt.Println("==> DONE")
 }